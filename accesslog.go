@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"goplot/httplog"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// used when Config.LogFormat is empty, the common log format
+var defaultLogFormat = []string{"RemoteHost", "RemoteUser", "TimeReceived", "RequestFirstLine", "Status", "ResponseBytes"}
+
+// access log fields by LogFormat token
+var logTokens = map[string]func(req *http.Request, rec *statusRecorder, received time.Time) string{
+	"RemoteHost": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		return remoteHost(req)
+	},
+	"RemoteUser": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		if user, _, ok := req.BasicAuth(); ok && user != "" {
+			return user
+		}
+		return "-"
+	},
+	"TimeReceived": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		return received.Format("[02/Jan/2006:15:04:05 -0700]")
+	},
+	"RequestFirstLine": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		return fmt.Sprintf("\"%s %s %s\"", req.Method, req.RequestURI, req.Proto)
+	},
+	"Status": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		return strconv.Itoa(rec.status)
+	},
+	"ResponseBytes": func(req *http.Request, rec *statusRecorder, received time.Time) string {
+		if rec.bytes == 0 {
+			return "-"
+		}
+		return strconv.FormatInt(rec.bytes, 10)
+	},
+}
+
+var errResponseTooLarge = errors.New("response exceeds MaxResponseBytes")
+
+// keeps the status code and body size of a response for logging, and cuts
+// the body off after limit bytes when limit is set
+type statusRecorder struct {
+	http.ResponseWriter
+	status    int
+	bytes     int64
+	limit     int64
+	truncated bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	if rec.truncated {
+		return 0, errResponseTooLarge
+	}
+	if rec.limit > 0 && rec.bytes+int64(len(b)) > rec.limit {
+		n, _ := rec.ResponseWriter.Write(b[:rec.limit-rec.bytes])
+		rec.bytes += int64(n)
+		rec.truncated = true
+		return n, errResponseTooLarge
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// streaming handlers need to flush through the recorder
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rec *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := rec.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("hijacking not supported")
+}
+
+// decides which successful requests get logged at Config.LogSampleRate
+type logSampler struct {
+	rate float64
+	mu   sync.Mutex
+	rng  *rand.Rand
+}
+
+func newLogSampler(rate float64, seed int64) *logSampler {
+	return &logSampler{rate: rate, rng: rand.New(rand.NewSource(seed))}
+}
+
+// errors (status >= 400) are always logged
+func (sampler *logSampler) keep(status int) bool {
+	if status >= 400 || sampler.rate >= 1 {
+		return true
+	}
+	sampler.mu.Lock()
+	defer sampler.mu.Unlock()
+	return sampler.rng.Float64() < sampler.rate
+}
+
+// writes an access log line for each request handled by h
+func accessLog(h http.Handler, logger *httplog.Logger, format []string, sampler *logSampler) http.Handler {
+	if len(format) == 0 {
+		format = defaultLogFormat
+	}
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		received := time.Now()
+		rec := &statusRecorder{ResponseWriter: c}
+		h.ServeHTTP(rec, req)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if !sampler.keep(rec.status) {
+			return
+		}
+
+		fields := make([]string, len(format))
+		for i, token := range format {
+			if field, ok := logTokens[token]; ok {
+				fields[i] = field(req, rec, received)
+			} else {
+				fields[i] = "-"
+			}
+		}
+		logger.Write([]byte(strings.Join(fields, " ") + "\n"))
+	})
+}
+
+// the client address without the port
+func remoteHost(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// truncates responses of h longer than max bytes, logging the request
+func limitResponse(h http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		rec := &statusRecorder{ResponseWriter: c, limit: max}
+		h.ServeHTTP(rec, req)
+		if rec.truncated {
+			fmt.Fprintf(os.Stderr, "%s %s: response truncated at %d bytes\n", req.Method, req.RequestURI, max)
+		}
+	})
+}
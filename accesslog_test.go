@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"goplot/httplog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the access log lines written for requests to the paths, at the sample rate
+func sampledLog(t *testing.T, rate float64, paths ...string) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := httplog.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[string]int{"/ok": http.StatusOK, "/missing": http.StatusNotFound, "/broken": http.StatusInternalServerError}
+	handler := accessLog(http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		c.WriteHeader(statuses[req.URL.Path])
+	}), logger, nil, newLogSampler(rate, 1))
+	for _, target := range paths {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	logger.Close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	lines := sampledLog(t, 0, "/ok", "/missing", "/ok", "/broken", "/ok")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"GET /missing HTTP/1.1" 404`) || !strings.Contains(lines[1], `"GET /broken HTTP/1.1" 500`) {
+		t.Errorf("at rate 0 got %q, want only the two errors", lines)
+	}
+	if lines := sampledLog(t, 1, "/ok", "/missing", "/ok"); len(lines) != 3 {
+		t.Errorf("at rate 1 got %q, want every request", lines)
+	}
+}
+
+func TestLimitResponse(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, 2*i)
+	}
+	form := url.Values{"dataseries": {data.String()}}
+	const limit = 1024
+
+	limited := limitResponse(http.HandlerFunc(dataSampleServer), limit)
+	rec := postForm(limited.ServeHTTP, "/goplot/viz", form)
+	if rec.Body.Len() != limit {
+		t.Errorf("got %d bytes, want the body cut at %d", rec.Body.Len(), limit)
+	}
+	if json.Valid(rec.Body.Bytes()) {
+		t.Error("truncated body is valid JSON")
+	}
+
+	// under the limit the body is left alone
+	rec = postForm(limitResponse(http.HandlerFunc(dataSampleServer), 64<<20).ServeHTTP, "/goplot/viz", form)
+	if rec.Body.Len() <= limit || !json.Valid(rec.Body.Bytes()) {
+		t.Errorf("got %d bytes of JSON, want the whole response", rec.Body.Len())
+	}
+}
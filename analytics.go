@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// how far back /admin/analytics looks
+const analyticsWindow = 24 * time.Hour
+
+// a handled request, as kept for /admin/analytics
+type requestRecord struct {
+	at        time.Time
+	bodyBytes int64
+	duration  time.Duration
+	endpoint  string
+}
+
+// the last requests in a ring buffer, the oldest overwritten first
+type RequestAnalytics struct {
+	mu      sync.Mutex
+	records []requestRecord
+	next    int
+}
+
+// the request analytics, set up by main
+var analytics *RequestAnalytics
+
+func NewRequestAnalytics(size int) *RequestAnalytics {
+	return &RequestAnalytics{records: make([]requestRecord, size)}
+}
+
+func (a *RequestAnalytics) Record(record requestRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.records[a.next] = record
+	a.next = (a.next + 1) % len(a.records)
+}
+
+// a histogram bucket, below Upper and from the previous bucket's Upper on
+type bucketCount struct {
+	Bucket string `json:"bucket"`
+	Count  int    `json:"count"`
+	upper  float64
+}
+
+// counts v into the first bucket it is below, the last one catching the rest
+func countInto(buckets []bucketCount, v float64) {
+	for i := range buckets {
+		if v < buckets[i].upper || i == len(buckets)-1 {
+			buckets[i].Count++
+			return
+		}
+	}
+}
+
+type analyticsSummary struct {
+	compute.Envelope
+	Requests int `json:"requests"`
+	// POST requests only
+	BodySizes []bucketCount `json:"bodySizes"`
+	Durations []bucketCount `json:"durations"`
+	// by the pattern the request was routed to
+	Endpoints map[string]int `json:"endpoints"`
+}
+
+// summarizes the requests of the last 24 hours, dropping the older ones
+func (a *RequestAnalytics) Summary(now time.Time) analyticsSummary {
+	summary := analyticsSummary{Envelope: compute.NewEnvelope(),
+		BodySizes: []bucketCount{{Bucket: "<1KB", upper: 1 << 10},
+			{Bucket: "1-10KB", upper: 10 << 10},
+			{Bucket: "10-100KB", upper: 100 << 10},
+			{Bucket: "100KB-1MB", upper: 1 << 20},
+			{Bucket: ">1MB"}},
+		Durations: []bucketCount{{Bucket: "<10ms", upper: float64(10 * time.Millisecond)},
+			{Bucket: "10-100ms", upper: float64(100 * time.Millisecond)},
+			{Bucket: "100ms-1s", upper: float64(time.Second)},
+			{Bucket: "1-10s", upper: float64(10 * time.Second)},
+			{Bucket: ">10s"}},
+		Endpoints: make(map[string]int)}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, record := range a.records {
+		if record.at.IsZero() {
+			continue
+		}
+		if now.Sub(record.at) > analyticsWindow {
+			a.records[i] = requestRecord{}
+			continue
+		}
+		summary.Requests++
+		if record.bodyBytes >= 0 {
+			countInto(summary.BodySizes, float64(record.bodyBytes))
+		}
+		countInto(summary.Durations, float64(record.duration))
+		summary.Endpoints[record.endpoint]++
+	}
+	return summary
+}
+
+// counts the bytes of a request body as the handler reads them, which also
+// covers chunked bodies of no announced length
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.n += int64(n)
+	return n, err
+}
+
+// body sizes, durations and endpoint counts of the requests of the last 24
+// hours, out of the last AnalyticsBufferSize
+// GET /admin/analytics with the X-Admin-Secret header
+func analyticsServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	if !adminAllowed(req) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	jsonSummary, err := json.Marshal(analytics.Summary(time.Now()))
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSummary)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the counts of the buckets, in order
+func bucketCounts(buckets []bucketCount) []int {
+	counts := make([]int, len(buckets))
+	for i, bucket := range buckets {
+		counts[i] = bucket.Count
+	}
+	return counts
+}
+
+func TestAnalyticsSummary(t *testing.T) {
+	now := time.Now()
+	a := NewRequestAnalytics(6)
+	// pushed out of the buffer by the last record
+	a.Record(requestRecord{at: now, bodyBytes: 1, duration: time.Millisecond, endpoint: "/goplot/overwritten"})
+	// out of the window
+	a.Record(requestRecord{at: now.Add(-25 * time.Hour), bodyBytes: 1, duration: time.Millisecond, endpoint: "/goplot/old"})
+	a.Record(requestRecord{at: now, bodyBytes: 10, duration: time.Millisecond, endpoint: "/goplot/viz"})
+	a.Record(requestRecord{at: now, bodyBytes: 2 << 10, duration: 50 * time.Millisecond, endpoint: "/goplot/viz"})
+	a.Record(requestRecord{at: now, bodyBytes: 200 << 10, duration: 500 * time.Millisecond, endpoint: "/goplot/viz"})
+	a.Record(requestRecord{at: now, bodyBytes: 5 << 20, duration: 20 * time.Second, endpoint: "/goplot/series/"})
+	// a GET, which has no body to count
+	a.Record(requestRecord{at: now, bodyBytes: -1, duration: 5 * time.Second, endpoint: "/healthz"})
+
+	summary := a.Summary(now)
+	if summary.Requests != 5 {
+		t.Errorf("got %d requests, want 5", summary.Requests)
+	}
+	if got, want := bucketCounts(summary.BodySizes), []int{1, 1, 0, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("body sizes %v, want %v", got, want)
+	}
+	if got, want := bucketCounts(summary.Durations), []int{1, 1, 1, 1, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("durations %v, want %v", got, want)
+	}
+	if want := map[string]int{"/goplot/viz": 3, "/goplot/series/": 1, "/healthz": 1}; !reflect.DeepEqual(summary.Endpoints, want) {
+		t.Errorf("endpoints %v, want %v", summary.Endpoints, want)
+	}
+	// the old record is dropped for good
+	if summary := a.Summary(now.Add(-24 * time.Hour)); summary.Endpoints["/goplot/old"] != 0 {
+		t.Errorf("old record kept: %v", summary.Endpoints)
+	}
+}
+
+func TestAnalyticsServer(t *testing.T) {
+	savedAnalytics, savedSecret := analytics, config.AdminSecret
+	defer func() { analytics, config.AdminSecret = savedAnalytics, savedSecret }()
+	analytics = NewRequestAnalytics(10)
+	config.AdminSecret = "s3cret"
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/goplot/echo", func(c http.ResponseWriter, req *http.Request) {
+		io.Copy(c, req.Body)
+	})
+	handler := recordLatency(mux)
+	for _, body := range []string{strings.Repeat("x", 2<<10), strings.Repeat("x", 20<<10), "1,2"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/goplot/echo", strings.NewReader(body)))
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/goplot/echo", nil))
+	// not routed, so not counted
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nowhere", nil))
+
+	rec := httptest.NewRecorder()
+	analyticsServer(rec, httptest.NewRequest("GET", "/admin/analytics", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("without the secret: got %d, want %d", rec.Code, http.StatusForbidden)
+	}
+	req := httptest.NewRequest("GET", "/admin/analytics", nil)
+	req.Header.Set("X-Admin-Secret", "s3cret")
+	rec = httptest.NewRecorder()
+	analyticsServer(rec, req)
+	var summary analyticsSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := bucketCounts(summary.BodySizes), []int{1, 1, 1, 0, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("body sizes %v, want %v", got, want)
+	}
+	if want := map[string]int{"/goplot/echo": 4}; !reflect.DeepEqual(summary.Endpoints, want) {
+		t.Errorf("endpoints %v, want %v", summary.Endpoints, want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// suffix of the series an anomaly is injected into
+const anomalySuffix = "_anomaly"
+
+// saves a copy of a stored series with an anomaly injected, as
+// {series}_anomaly, and sends back the fit of the copy. An earlier copy is
+// replaced; the original is left alone.
+// POST /goplot/inject-anomaly?series=name&type=spike&magnitude=5.0&at=50
+func injectAnomalyServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	name := req.FormValue("series")
+	as := name + anomalySuffix
+	if validSeriesName(name) != nil || validSeriesName(as) != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	apiKey := req.Header.Get("X-API-Key")
+	if !seriesAllowed(apiKey, name) || !seriesAllowed(apiKey, as) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	kind := req.FormValue("type")
+	if kind == "" {
+		kind = "spike"
+	}
+	magnitude, err := floatParam(req, "magnitude", 5.0)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	at, err := intParam(req, "at", 0)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	seed := time.Now().UnixNano()
+	if s := req.FormValue("seed"); s != "" {
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+	}
+
+	unlock := seriesLocks.LockCopy(name, as)
+	defer unlock()
+	if !seriesExists(name) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	injected, err := compute.InjectAnomaly(stored.Series, kind, magnitude, at, seed)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	// made before saving, so nothing is written when the copy can't be fitted
+	dataSample, err := compute.FitDataSample(injected, metadataFromRequest(req))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	jsonDataSample, err := json.Marshal(dataSample)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	if err = saveSeries(as, &StoredSeries{Series: injected}); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonDataSample)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestInjectAnomalySpike(t *testing.T) {
+	storeSeries(t, "spiky", "1,1\n2,2\n3,3\n4,4\n5,5")
+	rec := postForm(injectAnomalyServer, "/goplot/inject-anomaly?series=spiky&type=spike&magnitude=2&at=2", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	original, _ := loadSeries("spiky")
+	injected, _ := loadSeries("spiky" + anomalySuffix)
+	// y has a standard deviation of √2.5
+	for i, pt := range injected.Series {
+		want := original.Series[i].Y
+		if i == 2 {
+			want += 2 * math.Sqrt(2.5)
+		}
+		if math.Abs(pt.Y-want) > 1e-12 {
+			t.Errorf("point %d: got y %v, want %v", i, pt.Y, want)
+		}
+	}
+}
+
+// a series too short to fit gets no anomalous copy
+func TestInjectAnomalyUnfittable(t *testing.T) {
+	if err := saveSeries("lonely", &StoredSeries{Series: []compute.Point{{X: 1, Y: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	rec := postForm(injectAnomalyServer, "/goplot/inject-anomaly?series=lonely&at=0", nil)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got %d, want 422", rec.Code)
+	}
+	if seriesExists("lonely" + anomalySuffix) {
+		t.Error("the copy was saved")
+	}
+}
+
+// the spike stands out in the influence diagnostics of /goplot/viz
+func TestInjectedSpikeIsDetectable(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&data, "%d,%v\n", i, float64(i)+0.1*float64(i%3-1))
+	}
+	storeSeries(t, "watched", data.String())
+	if rec := postForm(injectAnomalyServer, "/goplot/inject-anomaly?series=watched&type=spike&magnitude=5&at=12", nil); rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	injected, err := loadSeries("watched" + anomalySuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var src strings.Builder
+	for _, pt := range injected.Series {
+		fmt.Fprintf(&src, "%v,%v\n", pt.X, pt.Y)
+	}
+
+	influence := postViz(t, url.Values{"dataseries": {src.String()}, "diagnostics": {"influence"}}).Influence
+	most := 0
+	for i := range influence {
+		if *influence[i].CooksDistance > *influence[most].CooksDistance {
+			most = i
+		}
+	}
+	// the usual 4/n cut-off for an influential point
+	if most != 12 || *influence[most].CooksDistance < 4.0/20 {
+		t.Errorf("most influential point %d with Cook's distance %v, want the spike at 12 over 0.2", most, *influence[most].CooksDistance)
+	}
+}
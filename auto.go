@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// fits models of increasing complexity and returns the one with the best AIC
+// POST /goplot/auto
+func autoServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	autoSample, err := compute.AutoFit(series)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	autoSample.Metadata = metadataFromRequest(req)
+
+	jsonAutoSample, err := json.Marshal(autoSample)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonAutoSample)
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestAutoDegenerate(t *testing.T) {
+	for data, want := range map[string]int{
+		"1,2\n2,4\n3,7\n4,8": http.StatusOK,
+		"1,5\n2,5\n3,5\n4,5": http.StatusUnprocessableEntity,
+		"1,2\n1,4\n1,7\n1,8": http.StatusUnprocessableEntity,
+		"1,2\n2,4":           http.StatusUnprocessableEntity,
+		"":                   http.StatusUnprocessableEntity,
+	} {
+		if rec := postForm(autoServer, "/goplot/auto", url.Values{"dataseries": {data}}); rec.Code != want {
+			t.Errorf("%q: got %d %s, want %d", data, rec.Code, rec.Body, want)
+		}
+	}
+}
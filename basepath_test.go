@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// starts goplot on a free port with the config settings in the JSON object
+// body, and returns its address once it accepts connections
+func startGoplot(t *testing.T, settings string) string {
+	t.Helper()
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := free.Addr().String()
+	free.Close()
+	configPath := writeConfig(t, `{"CustomLog": "nolog", "Address": "`+address+`", "DataDir": "`+t.TempDir()+`"`+settings+`}`)
+	cmd := exec.Command(os.Args[0], "-c", configPath)
+	cmd.Env = append(os.Environ(), "GOPLOT_TEST_MAIN=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if conn, err := net.Dial("tcp", address); err == nil {
+			conn.Close()
+			return address
+		}
+	}
+	t.Fatal("goplot didn't start listening")
+	return ""
+}
+
+func TestBasePath(t *testing.T) {
+	for _, basePath := range []string{"", "/monitoring"} {
+		root := "http://" + startGoplot(t, `, "BasePath": "`+basePath+`"`) + basePath
+		get := func(path string) (int, string) {
+			resp, err := http.Get(root + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			return resp.StatusCode, string(body)
+		}
+
+		resp, err := http.PostForm(root+"/goplot/series/based/append", url.Values{"dataseries": {"1,2\n2,4\n3,6"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("%q: appending got %d", basePath, resp.StatusCode)
+		}
+		if code, body := get("/goplot/series/based"); code != http.StatusOK || !strings.Contains(body, `"x":3`) {
+			t.Errorf("%q: reading the series got %d %s", basePath, code, body)
+		}
+		if code, _ := get("/healthz"); code != http.StatusOK {
+			t.Errorf("%q: /healthz got %d", basePath, code)
+		}
+		// html/template escapes the slash in a script
+		if code, body := get("/goplot"); code != http.StatusOK || !strings.Contains(body, `var basePath = '`+strings.ReplaceAll(basePath, "/", `\/`)+`'`) {
+			t.Errorf("%q: the dashboard got %d without the base path", basePath, code)
+		}
+		if basePath == "" {
+			continue
+		}
+		// nothing left at the bare paths
+		resp, err = http.Get(strings.TrimSuffix(root, basePath) + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%q: /healthz without the prefix got %d", basePath, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// method comparison of two matched series
+// POST /goplot/bland-altman with series1 and series2
+func blandAltmanServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	series1, series2, err := parseTwoSeries(req)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	ba, err := compute.BlandAltmanAnalysis(series1, series2)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonBA, err := json.Marshal(ba)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonBA)
+}
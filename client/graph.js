@@ -1,5 +1,9 @@
 var graph1;
 var board;
+// when set, the regression runs in the browser through goplot.wasm
+// instead of being posted to the server
+var useWasm = false;
+var wasmReady = false;
 
 function makeGraph(pack) {
   dataSeries = pack.series;
@@ -33,34 +37,48 @@ function makeGraph(pack) {
     }
   }
 
-  brd = JXG.JSXGraph.initBoard('jxgbox', {boundingbox: [xmin - 4, ymax + 4, xmax + 4, ymin - 4], axis: true, showNavigation: true});
+  // the server pads the bounds so no point sits on the edge
+  var box = [xmin - 4, ymax + 4, xmax + 4, ymin - 4];
+  if (pack.axisBounds) {
+    box = [pack.axisBounds.xmin, pack.axisBounds.ymax, pack.axisBounds.xmax, pack.axisBounds.ymin];
+  }
+  brd = JXG.JSXGraph.initBoard('jxgbox', {boundingbox: box, axis: true, showNavigation: true});
   brd.suspendUpdate();
 
-  points.push(brd.createElement('point', [xmin,0], {visible:false, name:'', fixed:true}));
-  for (i=start;i<end;i++) {
+  if (pack.density) {
+    drawDensity(brd, pack.density);
+  } else {
+    points.push(brd.createElement('point', [xmin,0], {visible:false, name:'', fixed:true}));
+    for (i=start;i<end;i++) {
 
-    x1 = dataSeries[i].x;
-    y1 = dataSeries[i].y;
+      x1 = dataSeries[i].x;
+      y1 = dataSeries[i].y;
 
-    // Plot it
-    p = brd.createElement('point', [x1,y1], 
-                  {strokeWidth:2, strokeColor:'#ffffff', 
-                   highlightStrokeColor:'#0077cc', fillColor:'#0077cc',  
-                   highlightFillColor:'#0077cc', style:6, name:'', fixed:true}
-                ); 
-    points.push(p);
-    x.push(x1);
-    y.push(y1);
-  }
-  // Filled area. We need two additional points [start,0] and [end,0]
-  points.push(brd.createElement('point', [xmax,0], {visible:false, name:'', fixed:true}));
-  brd.createElement('polygon',points, {withLines:false,fillColor:'#e6f2fa'});
+      // Plot it
+      p = brd.createElement('point', [x1,y1], 
+                    {strokeWidth:2, strokeColor:'#ffffff', 
+                     highlightStrokeColor:'#0077cc', fillColor:'#0077cc',  
+                     highlightFillColor:'#0077cc', style:6, name:'', fixed:true}
+                  ); 
+      points.push(p);
+      // error bar of one standard deviation
+      if (dataSeries[i].yerr) {
+        brd.createElement('segment', [[x1, y1 - dataSeries[i].yerr], [x1, y1 + dataSeries[i].yerr]],
+                    {strokeWidth:1, strokeColor:'#0077cc', fixed:true});
+      }
+      x.push(x1);
+      y.push(y1);
+    }
+    // Filled area. We need two additional points [start,0] and [end,0]
+    points.push(brd.createElement('point', [xmax,0], {visible:false, name:'', fixed:true}));
+    brd.createElement('polygon',points, {withLines:false,fillColor:'#e6f2fa'});
  
-  // Curve:
-  brd.createElement('curve', [x,y], 
-                 {strokeWidth:3, strokeColor:'#0077cc', 
-                  highlightStrokeColor:'#0077cc'}
-               );
+    // Curve:
+    brd.createElement('curve', [x,y], 
+                   {strokeWidth:3, strokeColor:'#0077cc', 
+                    highlightStrokeColor:'#0077cc'}
+                 );
+  }
   
   if (plotRegression) {
     // Regression line
@@ -79,11 +97,81 @@ function makeGraph(pack) {
                );
   }
 
+  if (pack.metadata) {
+    // axis titles, "label (unit)"
+    var xtitle = axisTitle(pack.metadata.xlabel, pack.metadata.xunit);
+    var ytitle = axisTitle(pack.metadata.ylabel, pack.metadata.yunit);
+    if (xtitle) {
+      brd.createElement('text', [xmax - 2, ymin - 2, xtitle], {fixed:true});
+    }
+    if (ytitle) {
+      brd.createElement('text', [xmin - 3, ymax + 2, ytitle], {fixed:true});
+    }
+  }
+
   brd.unsuspendUpdate();
   
   return brd;
 }
 
+// heatmap of the point counts, more opaque cells holding more points
+function drawDensity(brd, density) {
+  var w = (density.xmax - density.xmin) / density.columns || 1;
+  var h = (density.ymax - density.ymin) / density.rows || 1;
+  var row, column, count, x0, y0;
+  for (row = 0; row < density.rows; row++) {
+    for (column = 0; column < density.columns; column++) {
+      count = density.counts[row][column];
+      if (!count) {
+        continue;
+      }
+      x0 = density.xmin + column * w;
+      y0 = density.ymin + row * h;
+      brd.createElement('polygon', [[x0, y0], [x0 + w, y0], [x0 + w, y0 + h], [x0, y0 + h]],
+                  {fillColor:'#0077cc', fillOpacity:0.1 + 0.9 * count / density.maxCount,
+                   withLines:false, vertices:{visible:false}, fixed:true});
+    }
+  }
+}
+
+function axisTitle(label, unit) {
+  if (label && unit) {
+    return label + ' (' + unit + ')';
+  }
+  return label || unit || '';
+}
+
+// fetches and starts goplot.wasm once, then calls done. URLs are relative
+// to the viz page so they keep any Config.BasePath prefix.
+function loadWasm(done) {
+  if (wasmReady) {
+    done();
+    return;
+  }
+  $.getScript('wasm_exec.js', function() {
+    var go = new Go();
+    WebAssembly.instantiateStreaming(fetch('goplot.wasm'), go.importObject).then(function(result) {
+      go.run(result.instance);
+      wasmReady = true;
+      done();
+    });
+  });
+}
+
+function refreshChart(form) {
+  if (useWasm) {
+    loadWasm(function() {
+      var pack = JSON.parse(computeRegression($('#dataseries').val()));
+      pack.metadata = {xlabel: $('[name=xlabel]').val(), xunit: $('[name=xunit]').val(),
+                       ylabel: $('[name=ylabel]').val(), yunit: $('[name=yunit]').val()};
+      updateChart(pack);
+    });
+  } else {
+    $.post("viz", $(form).serialize(), updateChart, "json");
+  }
+  return false;
+}
+
 function updateChart(data, textStatus) {
   JXG.JSXGraph.freeBoard(board);
   makeGraph(data);
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"io"
+	"net/http"
+)
+
+// most series a comparison table takes
+const maxCompareSeries = 10
+
+var errTooManySeries = errors.New("at most 10 series can be compared")
+
+// a series to put in the comparison table, fitted with the model named by
+// Type, "linear" when not given
+type CompareSpec struct {
+	Label string `json:"label"`
+	Data  string `json:"data"`
+	Type  string `json:"type"`
+}
+
+// fits each posted series and returns a table of the fit metrics, a row per
+// series, best first by the sortBy metric (aic by default)
+// POST /goplot/compare-table?sortBy=aic [{"label": "s1", "data": "1,2\n...", "type": "linear"}, ...]
+func compareTableServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	sortBy := req.URL.Query().Get("sortBy")
+	if sortBy == "" {
+		sortBy = "aic"
+	}
+	var specs []CompareSpec
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxUploadBytes)).Decode(&specs); err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if len(specs) > maxCompareSeries {
+		serveErrorFor(c, req, &compute.ParseError{Err: errTooManySeries})
+		return
+	}
+
+	rows := make([]compute.ComparisonRow, len(specs))
+	for i, spec := range specs {
+		if spec.Type == "" {
+			spec.Type = "linear"
+		}
+		series, err := compute.ParseSeries(spec.Data)
+		if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		rows[i] = compute.CompareRow(spec.Label, spec.Type, series)
+	}
+	if err := compute.SortComparison(rows, sortBy); err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonTable, err := json.Marshal(struct {
+		compute.Envelope
+		Columns []string                `json:"columns"`
+		Rows    []compute.ComparisonRow `json:"rows"`
+	}{compute.NewEnvelope(), compute.ComparisonColumns, rows})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonTable)
+}
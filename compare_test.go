@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// posts the specs to /goplot/compare-table with the query
+func postCompare(t *testing.T, query string, specs []CompareSpec) *httptest.ResponseRecorder {
+	t.Helper()
+	body, err := json.Marshal(specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", "/goplot/compare-table"+query, strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+	compareTableServer(rec, req)
+	return rec
+}
+
+func TestCompareTable(t *testing.T) {
+	specs := []CompareSpec{
+		{Label: "scattered", Data: "1,1\n2,5\n3,2\n4,8\n5,4"},
+		{Label: "exact", Data: "1,2\n2,4\n3,6.01\n4,8\n5,10"},
+		{Label: "slightly off", Data: "1,2\n2,4.5\n3,6\n4,7.5\n5,10"},
+	}
+	rec := postCompare(t, "", specs)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var table struct {
+		Columns []string                 `json:"columns"`
+		Rows    []map[string]interface{} `json:"rows"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &table); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.Columns) != 8 || len(table.Rows) != len(specs) {
+		t.Fatalf("got %d columns and %d rows, want 8 and %d", len(table.Columns), len(table.Rows), len(specs))
+	}
+	for _, row := range table.Rows {
+		for _, column := range table.Columns {
+			if _, ok := row[column]; !ok {
+				t.Errorf("row %v has no %s column", row["label"], column)
+			}
+		}
+	}
+
+	aics := make([]float64, len(table.Rows))
+	for i, row := range table.Rows {
+		aics[i] = row["aic"].(float64)
+	}
+	if !sort.Float64sAreSorted(aics) {
+		t.Errorf("AIC not ascending: %v", aics)
+	}
+	for i, label := range []string{"exact", "slightly off", "scattered"} {
+		if table.Rows[i]["label"] != label {
+			t.Errorf("row %d is %v, want %s", i, table.Rows[i]["label"], label)
+		}
+	}
+}
+
+func TestCompareTableErrors(t *testing.T) {
+	spec := CompareSpec{Label: "s", Data: "1,2\n2,4\n3,7"}
+	tests := []struct {
+		name  string
+		query string
+		specs []CompareSpec
+		want  int
+	}{
+		{"unknown sortBy", "?sortBy=colour", []CompareSpec{spec}, http.StatusBadRequest},
+		{"too many series", "", make([]CompareSpec, maxCompareSeries+1), http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if rec := postCompare(t, test.query, test.specs); rec.Code != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, rec.Code, test.want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// the kinds of anomaly InjectAnomaly makes
+var AnomalyTypes = []string{"spike", "drift", "noise"}
+
+var ErrUnknownAnomaly = errors.New("unknown anomaly type")
+var ErrAnomalyIndex = errors.New("anomaly index out of range")
+
+// returns a copy of the series with an anomaly of magnitude standard
+// deviations of Y added to it:
+// "spike" adds it to the Y of point at
+// "drift" ramps Y up from point at, reaching the full magnitude at the end
+// "noise" adds Gaussian noise with that standard deviation to every Y
+func InjectAnomaly(series []Point, kind string, magnitude float64, at int, seed int64) ([]Point, error) {
+	if kind != "noise" && (at < 0 || at >= len(series)) {
+		return nil, ErrAnomalyIndex
+	}
+	n := float64(len(series))
+	mean, variance := 0.0, 0.0
+	for _, pt := range series {
+		mean += pt.Y / n
+	}
+	for _, pt := range series {
+		variance += (pt.Y - mean) * (pt.Y - mean) / (n - 1)
+	}
+	size := magnitude * math.Sqrt(variance)
+	if len(series) < 2 {
+		size = 0
+	}
+
+	injected := make([]Point, len(series))
+	copy(injected, series)
+	switch kind {
+	case "spike":
+		injected[at].Y += size
+	case "drift":
+		for i := at; i < len(injected); i++ {
+			injected[i].Y += size * float64(i-at+1) / float64(len(injected)-at)
+		}
+	case "noise":
+		rng := rand.New(rand.NewSource(seed))
+		for i := range injected {
+			injected[i].Y += size * rng.NormFloat64()
+		}
+	default:
+		return nil, ErrUnknownAnomaly
+	}
+	return injected, nil
+}
@@ -0,0 +1,88 @@
+package compute
+
+import (
+	"math"
+	"time"
+)
+
+// highest polynomial degree tried by the auto fit
+const autoMaxDegree = 6
+
+// one model tried by the auto fit
+type Candidate struct {
+	Model            string  `json:"model"`
+	Degree           int     `json:"degree"`
+	AIC              float64 `json:"aic"`
+	ProcessingTimeMs float64 `json:"processingTimeMs"`
+}
+
+type AutoSample struct {
+	DataSample
+	Candidates []Candidate `json:"candidates"`
+}
+
+// tries linear, then polynomials of increasing degree, stopping as soon as
+// a model fails to improve the AIC by more than 2. Fails with a
+// *RegressionError when there is nothing to choose between: for fewer than
+// 3 points, a single x or a single y.
+func AutoFit(series []Point) (*AutoSample, error) {
+	if err := CheckFittable("linear", series); err != nil {
+		return nil, err
+	}
+	sameY := true
+	for _, pt := range series {
+		sameY = sameY && pt.Y == series[0].Y
+	}
+	if sameY {
+		return nil, &RegressionError{Model: "linear", Err: ErrSameY}
+	}
+	n := float64(len(series))
+	candidates := make([]Candidate, 0)
+
+	start := time.Now()
+	best := FitLine(series)
+	sr, _ := SumsOfSquares(series, []float64{best.Intercept, best.Slope})
+	bestAIC := aic(sr, n, 2)
+	candidates = append(candidates, Candidate{Model: "linear", Degree: 1, AIC: bestAIC,
+		ProcessingTimeMs: milliseconds(time.Since(start))})
+
+	for degree := 2; degree <= autoMaxDegree && degree+1 < len(series); degree++ {
+		start = time.Now()
+		coefficients, err := PolynomialRegression(series, degree)
+		if err != nil {
+			break
+		}
+		sr, st := SumsOfSquares(series, coefficients)
+		score := aic(sr, n, degree+1)
+		candidates = append(candidates, Candidate{Model: "polynomial", Degree: degree, AIC: score,
+			ProcessingTimeMs: milliseconds(time.Since(start))})
+		if score >= bestAIC-2 {
+			break
+		}
+		bestAIC = score
+		best = RegressionLine{Slope: coefficients[1], Intercept: coefficients[0],
+			StdError:     math.Sqrt(sr / (n - float64(degree+1))),
+			Correlation:  math.Sqrt((st - sr) / st),
+			Equation:     lineEquation("polynomial", coefficients, math.Sqrt((st-sr)/st)),
+			Model:        "polynomial",
+			Coefficients: coefficients}
+	}
+
+	return &AutoSample{DataSample: DataSample{Series: series, Envelope: NewEnvelope(), RegressionLine: &best},
+		Candidates: candidates}, nil
+}
+
+// Akaike information criterion for a least squares fit with k parameters.
+// An exact fit is clamped so the score stays finite.
+func aic(sr float64, n float64, k int) float64 {
+	return n*math.Log(math.Max(sr/n, math.SmallestNonzeroFloat64)) + 2*float64(k)
+}
+
+// Bayesian information criterion, clamped like aic
+func bic(sr float64, n float64, k int) float64 {
+	return n*math.Log(math.Max(sr/n, math.SmallestNonzeroFloat64)) + float64(k)*math.Log(n)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,55 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestAutoFit(t *testing.T) {
+	tests := []struct {
+		name   string
+		f      func(x float64) float64
+		model  string
+		degree int
+	}{
+		{"quadratic", func(x float64) float64 { return 2*x*x - 3*x + 1 }, "polynomial", 2},
+		{"linear", func(x float64) float64 { return 4*x - 2 }, "", 1},
+	}
+	for _, test := range tests {
+		var series []Point
+		for i := 0; i < 30; i++ {
+			x := float64(i) / 3
+			// small deterministic noise, so the fits aren't exact
+			series = append(series, Point{X: x, Y: test.f(x) + 0.3*math.Sin(1.7*float64(i))})
+		}
+		sample, err := AutoFit(series)
+		if err != nil {
+			t.Fatal(err)
+		}
+		line := sample.RegressionLine
+		if line.Model != test.model || (test.model == "polynomial" && len(line.Coefficients) != test.degree+1) {
+			t.Errorf("%s: selected %q with coefficients %v", test.name, line.Model, line.Coefficients)
+		}
+		if sample.Candidates[0].Model != "linear" {
+			t.Errorf("%s: tried %q first, want linear", test.name, sample.Candidates[0].Model)
+		}
+	}
+}
+
+func TestAutoFitDegenerate(t *testing.T) {
+	tests := []struct {
+		series []Point
+		err    error
+	}{
+		{[]Point{{X: 1, Y: 2}, {X: 2, Y: 4}}, ErrTooFewPoints},
+		{[]Point{{X: 1, Y: 2}, {X: 1, Y: 4}, {X: 1, Y: 7}}, ErrSameX},
+		{[]Point{{X: 1, Y: 5}, {X: 2, Y: 5}, {X: 3, Y: 5}, {X: 4, Y: 5}}, ErrSameY},
+	}
+	for _, test := range tests {
+		var regressionError *RegressionError
+		if _, err := AutoFit(test.series); !errors.As(err, &regressionError) || !errors.Is(err, test.err) {
+			t.Errorf("%v: got %v, want %v", test.series, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrUnmatchedSeries = errors.New("series must have the same length and the same X values")
+
+type BAPoint struct {
+	X    float64 `json:"x"`
+	Mean float64 `json:"mean"`
+	Diff float64 `json:"diff"`
+}
+
+// agreement between two measurement methods; the limits of agreement are
+// bias ± 1.96 standard deviations of the differences
+type BlandAltman struct {
+	Envelope
+	Points   []BAPoint `json:"points"`
+	Bias     float64   `json:"bias"`
+	UpperLOA float64   `json:"upperLOA"`
+	LowerLOA float64   `json:"lowerLOA"`
+}
+
+// compares two series measured at the same X values, pairwise in order
+func BlandAltmanAnalysis(series1, series2 []Point) (*BlandAltman, error) {
+	n := len(series1)
+	if n != len(series2) {
+		return nil, ErrUnmatchedSeries
+	}
+	if n < 2 {
+		return nil, errors.New("at least 2 pairs are needed")
+	}
+	ba := &BlandAltman{Envelope: NewEnvelope(), Points: make([]BAPoint, n)}
+	for i := range series1 {
+		if series1[i].X != series2[i].X {
+			return nil, ErrUnmatchedSeries
+		}
+		y1, y2 := series1[i].Y, series2[i].Y
+		ba.Points[i] = BAPoint{X: series1[i].X, Mean: (y1 + y2) / 2, Diff: y1 - y2}
+		ba.Bias += y1 - y2
+	}
+	ba.Bias /= float64(n)
+
+	variance := 0.0
+	for _, p := range ba.Points {
+		variance += (p.Diff - ba.Bias) * (p.Diff - ba.Bias)
+	}
+	sd := math.Sqrt(variance / float64(n-1))
+	ba.UpperLOA = ba.Bias + 1.96*sd
+	ba.LowerLOA = ba.Bias - 1.96*sd
+	return ba, nil
+}
@@ -0,0 +1,46 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestBlandAltmanAnalysis(t *testing.T) {
+	series1 := []Point{{X: 1, Y: 10}, {X: 2, Y: 12.5}, {X: 3, Y: 9}, {X: 4, Y: 14}}
+	offset := make([]Point, len(series1))
+	alternating := make([]Point, len(series1))
+	for i, pt := range series1 {
+		offset[i] = Point{X: pt.X, Y: pt.Y - 1.5}
+		alternating[i] = Point{X: pt.X, Y: pt.Y + float64(2*(i%2)-1)}
+	}
+	sd := math.Sqrt(4.0 / 3)
+	tests := []struct {
+		name                     string
+		series2                  []Point
+		bias, upperLOA, lowerLOA float64
+	}{
+		{"identical", series1, 0, 0, 0},
+		{"offset by a constant", offset, 1.5, 1.5, 1.5},
+		{"differences of ±1", alternating, 0, 1.96 * sd, -1.96 * sd},
+	}
+	for _, test := range tests {
+		ba, err := BlandAltmanAnalysis(series1, test.series2)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if math.Abs(ba.Bias-test.bias) > 1e-12 || math.Abs(ba.UpperLOA-test.upperLOA) > 1e-12 || math.Abs(ba.LowerLOA-test.lowerLOA) > 1e-12 {
+			t.Errorf("%s: got bias %v LOA %v..%v, want %v %v..%v", test.name, ba.Bias, ba.LowerLOA, ba.UpperLOA, test.bias, test.lowerLOA, test.upperLOA)
+		}
+		if p := ba.Points[1]; p.X != 2 || !closeTo(p.Mean, (12.5+test.series2[1].Y)/2) || !closeTo(p.Diff, 12.5-test.series2[1].Y) {
+			t.Errorf("%s: point %+v", test.name, p)
+		}
+	}
+
+	if _, err := BlandAltmanAnalysis(series1, series1[:3]); !errors.Is(err, ErrUnmatchedSeries) {
+		t.Errorf("different lengths: got %v", err)
+	}
+	if _, err := BlandAltmanAnalysis(series1, []Point{{X: 1}, {X: 2}, {X: 5}, {X: 4}}); !errors.Is(err, ErrUnmatchedSeries) {
+		t.Errorf("different X: got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package compute
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// resamples the series with replacement, refits each resample and returns
+// the 2.5 and 97.5 percentiles of the slope
+func BootstrapSlope(series []Point, resamples int, seed int64) []float64 {
+	interval, _ := BootstrapSlopeContext(context.Background(), series, resamples, seed)
+	return interval
+}
+
+// like BootstrapSlope, but stops resampling once ctx is done and returns the
+// interval from the resamples made so far, with approximate set
+func BootstrapSlopeContext(ctx context.Context, series []Point, resamples int, seed int64) (interval []float64, approximate bool) {
+	n := len(series)
+	if n < 2 {
+		return nil, false
+	}
+	rng := rand.New(rand.NewSource(seed))
+	resample := make([]Point, n)
+	slopes := make([]float64, 0, resamples)
+	for b := 0; b < resamples; b++ {
+		if ctx.Err() != nil {
+			approximate = true
+			break
+		}
+		for i := range resample {
+			resample[i] = series[rng.Intn(n)]
+		}
+		slope, _, _, _ := LinearRegression(resample)
+		// a resample with a single distinct x has no slope
+		if !math.IsNaN(slope) && !math.IsInf(slope, 0) {
+			slopes = append(slopes, slope)
+		}
+	}
+	if len(slopes) == 0 {
+		return nil, approximate
+	}
+	sort.Float64s(slopes)
+	return []float64{Percentile(slopes, 0.025), Percentile(slopes, 0.975)}, approximate
+}
+
+// p-th quantile of sorted values, interpolating between neighbours
+func Percentile(sorted []float64, p float64) float64 {
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
@@ -0,0 +1,43 @@
+package compute
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// y = 2x + 1 with a little deterministic noise
+func cleanLine(n int) []Point {
+	series := make([]Point, n)
+	for i := range series {
+		x := float64(i)
+		series[i] = Point{X: x, Y: 2*x + 1 + 0.5*math.Sin(2.3*x)}
+	}
+	return series
+}
+
+func TestBootstrapSlopeBracketsTrueSlope(t *testing.T) {
+	series := cleanLine(50)
+	interval := BootstrapSlope(series, 1000, 1)
+	if len(interval) != 2 || !(interval[0] < 2 && 2 < interval[1]) {
+		t.Fatalf("interval %v doesn't bracket the slope 2", interval)
+	}
+	if again := BootstrapSlope(series, 1000, 1); !reflect.DeepEqual(again, interval) {
+		t.Errorf("the same seed gave %v, then %v", interval, again)
+	}
+}
+
+func TestBootstrapSlopeBudget(t *testing.T) {
+	series := cleanLine(50)
+	if _, approximate := BootstrapSlopeContext(context.Background(), series, 100, 1); approximate {
+		t.Error("approximate without a deadline")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, approximate := BootstrapSlopeContext(ctx, series, 100, 1); !approximate {
+		t.Error("not approximate after the budget ran out")
+	}
+}
@@ -0,0 +1,139 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// the models FitShape fits, by name
+var ShapeModels = []string{"linear", "logarithmic", "exponential", "powerlaw"}
+
+var ErrUnknownShape = errors.New("unknown regression type")
+var ErrUnknownSortKey = errors.New("unknown sort key")
+
+// fits the series with one of ShapeModels
+func FitShape(series []Point, model string) (RegressionLine, error) {
+	positiveX, positiveY := true, true
+	for _, pt := range series {
+		positiveX = positiveX && pt.X > 0
+		positiveY = positiveY && pt.Y > 0
+	}
+	switch model {
+	case "linear":
+		return FitLine(series), nil
+	case "logarithmic":
+		if !positiveX {
+			return RegressionLine{}, &RegressionError{Model: model, Err: errors.New("a logarithmic fit needs positive x")}
+		}
+		return logarithmicFit(series), nil
+	case "exponential":
+		if !positiveY {
+			return RegressionLine{}, &RegressionError{Model: model, Err: errors.New("an exponential fit needs positive y")}
+		}
+		return exponentialFit(series), nil
+	case "powerlaw":
+		return LogLogFit(series)
+	}
+	return RegressionLine{}, ErrUnknownShape
+}
+
+// the fitted value at x, by the line's model
+func (line *RegressionLine) ValueAt(x float64) float64 {
+	c := line.Coefficients
+	switch line.Model {
+	case "logarithmic":
+		return c[0] + c[1]*math.Log(x)
+	case "exponential":
+		return c[0] * math.Exp(c[1]*x)
+	case "powerlaw":
+		return c[0] * math.Pow(x, c[1])
+	case "polynomial":
+		y := 0.0
+		for i := len(c) - 1; i >= 0; i-- {
+			y = y*x + c[i]
+		}
+		return y
+	}
+	return line.Slope*x + line.Intercept
+}
+
+// a row of a comparison table, the fit of one series by one model. Error
+// is set, and the metrics left 0, when the series couldn't be fitted.
+type ComparisonRow struct {
+	Label       string  `json:"label"`
+	Type        string  `json:"type"`
+	Slope       float64 `json:"slope"`
+	Intercept   float64 `json:"intercept"`
+	Correlation float64 `json:"correlation"`
+	RSquared    float64 `json:"rSquared"`
+	AIC         float64 `json:"aic"`
+	BIC         float64 `json:"bic"`
+	StdError    float64 `json:"stdError"`
+	N           int     `json:"n"`
+	Error       string  `json:"error,omitempty"`
+}
+
+// the metric columns of a comparison table, in order
+var ComparisonColumns = []string{"slope", "intercept", "correlation", "rSquared", "aic", "bic", "stdError", "n"}
+
+// fits the series with the model and scores the fit. AIC and BIC are of the
+// residuals in the original units, so models fitted on transformed axes
+// compare fairly with a straight line.
+func CompareRow(label string, model string, series []Point) ComparisonRow {
+	row := ComparisonRow{Label: label, Type: model, N: len(series)}
+	if len(series) < 3 {
+		row.Error = "at least 3 points are needed"
+		return row
+	}
+	line, err := FitShape(series, model)
+	if err == nil && (math.IsNaN(line.Slope) || math.IsNaN(line.Correlation)) {
+		err = &RegressionError{Model: model, Err: errors.New("the points have too little spread")}
+	}
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	sr := 0.0
+	for _, pt := range series {
+		r := pt.Y - line.ValueAt(pt.X)
+		sr += r * r
+	}
+	n := float64(len(series))
+	row.Slope, row.Intercept = line.Slope, line.Intercept
+	row.Correlation, row.RSquared = line.Correlation, line.Correlation*line.Correlation
+	row.StdError = line.StdError
+	row.AIC = aic(sr, n, 2)
+	row.BIC = bic(sr, n, 2)
+	return row
+}
+
+// orders the rows by a metric, best first: ascending for aic, bic and
+// stdError, descending for rSquared and correlation, or by label. Rows with
+// an error go last.
+func SortComparison(rows []ComparisonRow, by string) error {
+	var less func(a, b *ComparisonRow) bool
+	switch by {
+	case "aic":
+		less = func(a, b *ComparisonRow) bool { return a.AIC < b.AIC }
+	case "bic":
+		less = func(a, b *ComparisonRow) bool { return a.BIC < b.BIC }
+	case "stdError":
+		less = func(a, b *ComparisonRow) bool { return a.StdError < b.StdError }
+	case "rSquared":
+		less = func(a, b *ComparisonRow) bool { return a.RSquared > b.RSquared }
+	case "correlation":
+		less = func(a, b *ComparisonRow) bool { return a.Correlation > b.Correlation }
+	case "label":
+		less = func(a, b *ComparisonRow) bool { return a.Label < b.Label }
+	default:
+		return ErrUnknownSortKey
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if (rows[i].Error == "") != (rows[j].Error == "") {
+			return rows[i].Error == ""
+		}
+		return less(&rows[i], &rows[j])
+	})
+	return nil
+}
@@ -0,0 +1,323 @@
+// Package compute holds goplot's pure computation: parsing data series and
+// fitting regressions. It has no server dependencies, so it also builds
+// for js/wasm and runs in the browser.
+package compute
+
+import (
+	"encoding/xml"
+	"errors"
+	"math"
+	"time"
+)
+
+// version of the JSON response format, bumped on breaking changes
+const APIVersion = "1"
+
+// embedded in every JSON response so the client can tell formats apart
+type Envelope struct {
+	APIVersion string `json:"apiVersion" xml:"apiVersion"`
+}
+
+func NewEnvelope() Envelope {
+	return Envelope{APIVersion: APIVersion}
+}
+
+type Point struct {
+	X float64 `json:"x" xml:"x"`
+	Y float64 `json:"y" xml:"y"`
+	// uncertainty of Y (one standard deviation), 0 when not given
+	YErr float64 `json:"yerr,omitempty" xml:"yerr,omitempty"`
+	// the 1-based input line it was read from, with ParseOptions.LineNumbers
+	Line int `json:"line,omitempty" xml:"line,omitempty"`
+}
+
+type RegressionLine struct {
+	Slope       float64 `json:"slope" xml:"slope"`
+	Intercept   float64 `json:"intercept" xml:"intercept"`
+	StdError    float64 `json:"stdError" xml:"stdError"`
+	Correlation float64 `json:"correlation" xml:"correlation"`
+	// where the line crosses y=0. A flat line never does (or does
+	// everywhere); JSON has no Inf or NaN, so that is flagged instead.
+	XIntercept          float64 `json:"xIntercept" xml:"xIntercept"`
+	XInterceptUndefined bool    `json:"xInterceptUndefined,omitempty" xml:"xInterceptUndefined,omitempty"`
+	// the line at the mean x, which equals the mean y for a least squares fit
+	FittedAtMeanX float64 `json:"fittedAtMeanX" xml:"fittedAtMeanX"`
+	Equation      string  `json:"equation" xml:"equation"`
+	// set for non-linear models; polynomial coefficients are in ascending
+	// powers of x, the others are as in formatEquation
+	Model        string    `json:"model,omitempty" xml:"model,omitempty"`
+	Coefficients []float64 `json:"coefficients,omitempty" xml:"coefficient,omitempty"`
+	// sum of |residual|, what a least absolute deviations fit minimizes
+	SumAbsResiduals float64 `json:"sumAbsResiduals,omitempty" xml:"sumAbsResiduals,omitempty"`
+}
+
+// axis labels and units supplied by the client, echoed back for rendering
+type Metadata struct {
+	XLabel string `json:"xlabel" xml:"xlabel"`
+	YLabel string `json:"ylabel" xml:"ylabel"`
+	XUnit  string `json:"xunit" xml:"xunit"`
+	YUnit  string `json:"yunit" xml:"yunit"`
+	Geo    *Geo   `json:"geo,omitempty" xml:"geo,omitempty"`
+}
+
+type DataSample struct {
+	XMLName xml.Name `json:"-" xml:"dataSample"`
+	Series  []Point  `json:"series" xml:"series>point"`
+	Envelope
+	RegressionLine *RegressionLine `json:"regressionLine,omitempty" xml:"regressionLine,omitempty"` // nil when skipped
+	Metadata       Metadata        `json:"metadata" xml:"metadata"`
+	// from the header row of the input, when it has one
+	ColumnNames []string `json:"columnNames,omitempty" xml:"columnName,omitempty"`
+	// input lines that didn't hold two numbers, and why the first one didn't
+	SkippedLines int    `json:"skippedLines,omitempty" xml:"skippedLines,omitempty"`
+	ParseError   string `json:"parseError,omitempty" xml:"parseError,omitempty"`
+	// 2.5 and 97.5 percentile slopes, when bootstrapping was requested
+	BootstrapSlopeInterval []float64 `json:"bootstrapSlopeInterval,omitempty" xml:"bootstrapSlope,omitempty"`
+	// with trim set, the fit before the worst points were dropped, and how
+	// many were
+	UntrimmedLine *RegressionLine `json:"untrimmedLine,omitempty" xml:"untrimmedLine,omitempty"`
+	TrimmedCount  int             `json:"trimmedCount,omitempty" xml:"trimmedCount,omitempty"`
+	// points outside the xmin/xmax range, left out of the fit
+	ExcludedCount  int     `json:"excludedCount,omitempty" xml:"excludedCount,omitempty"`
+	ExcludedPoints []Point `json:"excludedPoints,omitempty" xml:"excludedPoint,omitempty"`
+	// set when the time budget ran out before all resamples were made
+	Approximate bool `json:"approximate,omitempty" xml:"approximate,omitempty"`
+	// set when the points are geographic coordinates (inputCRS=WGS84)
+	GeoBounds *GeoBounds `json:"geoBounds,omitempty" xml:"geoBounds,omitempty"`
+	// how reliable the regression is, see Quality
+	QualityScore float64 `json:"qualityScore,omitempty" xml:"qualityScore,omitempty"`
+	QualityGrade string  `json:"qualityGrade,omitempty" xml:"qualityGrade,omitempty"`
+	// the padded ranges to draw the series in
+	AxisBounds *AxisBounds `json:"axisBounds,omitempty" xml:"axisBounds,omitempty"`
+	// round axis tick values covering the axis bounds, when ticks were
+	// requested
+	XTicks []float64 `json:"xTicks,omitempty" xml:"xTick,omitempty"`
+	YTicks []float64 `json:"yTicks,omitempty" xml:"yTick,omitempty"`
+	// how long the request took to process, when timing was requested
+	Timing *Timing `json:"timing,omitempty" xml:"timing,omitempty"`
+	// with regressionType=auto, the model picked and the |r| of each
+	// linearizing transform that was tried
+	AutoSelectedType string             `json:"autoSelectedType,omitempty" xml:"autoSelectedType,omitempty"`
+	HeuristicScores  map[string]float64 `json:"heuristicScores,omitempty" xml:"-"`
+	// per point, in series order, with diagnostics=influence
+	Influence []Influence `json:"influence,omitempty" xml:"influence,omitempty"`
+	// point counts for a heatmap, with render=density
+	Density *DensityGrid `json:"density,omitempty" xml:"density,omitempty"`
+	// where the data came from, with Config.TrackProvenance
+	Provenance *Provenance `json:"provenance,omitempty" xml:"provenance,omitempty"`
+}
+
+// the request a result was computed from
+type Provenance struct {
+	ReceivedAt time.Time `json:"receivedAt" xml:"receivedAt"`
+	// hex SHA-256 of the raw request body
+	BodyHash             string `json:"bodyHash" xml:"bodyHash"`
+	RemoteAddr           string `json:"remoteAddr" xml:"remoteAddr"`
+	UserAgent            string `json:"userAgent" xml:"userAgent"`
+	ProcessingDurationMs int64  `json:"processingDurationMs" xml:"processingDurationMs"`
+}
+
+// processing times in milliseconds
+type Timing struct {
+	ParseMs      float64 `json:"parseMs" xml:"parseMs"`
+	RegressionMs float64 `json:"regressionMs" xml:"regressionMs"`
+	TotalMs      float64 `json:"totalMs" xml:"totalMs"`
+}
+
+// runs the regression over the series
+func NewDataSample(series []Point, metadata Metadata) *DataSample {
+	line := FitLine(series)
+	return &DataSample{Series: series,
+		Envelope:       NewEnvelope(),
+		RegressionLine: &line,
+		Metadata:       metadata}
+}
+
+var ErrTooFewPoints = errors.New("at least 3 points are needed for a fit")
+
+// runs the regression over the series like NewDataSample, failing with a
+// *RegressionError where the line or its standard error is undefined: for
+// fewer than 3 points or all of them at one x. When all y are the same the
+// correlation is undefined as well and given as 0, as JSON has no NaN.
+func FitDataSample(series []Point, metadata Metadata) (*DataSample, error) {
+	if err := CheckFittable("linear", series); err != nil {
+		return nil, err
+	}
+	dataSample := NewDataSample(series, metadata)
+	dataSample.RegressionLine.ZeroUndefinedCorrelation()
+	return dataSample, nil
+}
+
+// fails with a *RegressionError for the model where a fit of the series has
+// no standard error: for fewer than 3 points or all of them at one x
+func CheckFittable(model string, series []Point) error {
+	if len(series) < 3 {
+		return &RegressionError{Model: model, Err: ErrTooFewPoints}
+	}
+	sameX := true
+	for _, pt := range series {
+		sameX = sameX && pt.X == series[0].X
+	}
+	if sameX {
+		return &RegressionError{Model: model, Err: ErrSameX}
+	}
+	return nil
+}
+
+// gives an undefined correlation, of points that all have the same y, as 0
+// since JSON has no NaN
+func (line *RegressionLine) ZeroUndefinedCorrelation() {
+	if !math.IsNaN(line.Correlation) {
+		return
+	}
+	line.Correlation = 0
+	if line.Coefficients != nil {
+		line.Equation = lineEquation(line.Model, line.Coefficients, 0)
+	} else {
+		line.Equation = lineEquation("linear", []float64{line.Intercept, line.Slope}, 0)
+	}
+}
+
+// linear regression over the series, as sent to the client. When every
+// point has a Y error the fit is weighted by 1/σ².
+func FitLine(series []Point) RegressionLine {
+	slope, intercept, stdError, correlation := LinearRegression(series)
+	if weights := errorWeights(series); weights != nil {
+		slope, intercept = weightedLine(series, weights)
+		stdError, correlation = residualStats(series, slope, intercept)
+	}
+	line := RegressionLine{Slope: slope,
+		Intercept:   intercept,
+		StdError:    stdError,
+		Correlation: correlation,
+		Equation:    lineEquation("linear", []float64{intercept, slope}, correlation)}
+	line.setCrossings(series)
+	return line
+}
+
+// 1/σ² weights from the Y errors, nil unless every point has one
+func errorWeights(series []Point) []float64 {
+	weights := make([]float64, len(series))
+	for i, pt := range series {
+		if pt.YErr <= 0 {
+			return nil
+		}
+		weights[i] = 1 / (pt.YErr * pt.YErr)
+	}
+	return weights
+}
+
+// standard error and correlation of a line fitted by other means than
+// LinearRegression
+func residualStats(series []Point, slope, intercept float64) (stdError float64, correlation float64) {
+	n := float64(len(series))
+	ymean := 0.0
+	for _, pt := range series {
+		ymean += pt.Y / n
+	}
+	sr, st := 0.0, 0.0
+	for _, pt := range series {
+		r := pt.Y - (slope*pt.X + intercept)
+		sr += r * r
+		st += (pt.Y - ymean) * (pt.Y - ymean)
+	}
+	// unlike least squares the fit can be worse than the mean in squares
+	return math.Sqrt(sr / (n - 2)), math.Sqrt(math.Max(st-sr, 0) / st)
+}
+
+// weighted least squares line
+func weightedLine(series []Point, weights []float64) (slope, intercept float64) {
+	sumw, sumx, sumy, sumxy, sumx2 := 0.0, 0.0, 0.0, 0.0, 0.0
+	for i, pt := range series {
+		w := weights[i]
+		sumw += w
+		sumx += w * pt.X
+		sumy += w * pt.Y
+		sumxy += w * pt.X * pt.Y
+		sumx2 += w * pt.X * pt.X
+	}
+	slope = (sumw*sumxy - sumx*sumy) / (sumw*sumx2 - sumx*sumx)
+	intercept = (sumy - slope*sumx) / sumw
+	return slope, intercept
+}
+
+// sets XIntercept and FittedAtMeanX from the slope and intercept
+func (line *RegressionLine) setCrossings(series []Point) {
+	if line.Slope == 0 || math.IsNaN(line.Slope) || math.IsInf(line.Slope, 0) {
+		line.XInterceptUndefined = true
+	} else {
+		line.XIntercept = -line.Intercept / line.Slope
+	}
+	sumx := 0.0
+	for _, pt := range series {
+		sumx += pt.X
+	}
+	line.FittedAtMeanX = line.Slope*sumx/float64(len(series)) + line.Intercept
+}
+
+// the running sums LinearRegression works from, for debugging
+type Sums struct {
+	SumX, SumY, SumXY, SumX2 float64
+	XMean, YMean             float64
+}
+
+func SeriesSums(series []Point) (sums Sums) {
+	for _, pt := range series {
+		sums.SumX += pt.X
+		sums.SumY += pt.Y
+		sums.SumXY += pt.X * pt.Y
+		sums.SumX2 += pt.X * pt.X
+	}
+	sums.XMean = sums.SumX / float64(len(series))
+	sums.YMean = sums.SumY / float64(len(series))
+	return sums
+}
+
+// perform linear regression on the data series
+// based on Numerical Methods for Engineers, 2nd ed. by Chapra & Canal
+func LinearRegression(series []Point) (slope float64, intercept float64, stdError float64, correlation float64) {
+	len := len(series)
+	flen := float64(len) // convenience
+	sumx := 0.0
+	sumy := 0.0
+	sumxy := 0.0
+	sumx2 := 0.0
+	for ix := 0; ix < len; ix++ {
+		x := series[ix].X
+		y := series[ix].Y
+		sumx += x
+		sumy += y
+		sumxy += x * y
+		sumx2 += x * x
+	}
+	xmean := sumx / flen
+	ymean := sumy / flen
+	slope = (flen*sumxy - sumx*sumy) / (flen*sumx2 - sumx*sumx)
+	intercept = ymean - slope*xmean
+
+	st := 0.0
+	sr := 0.0
+	for ix := 0; ix < len; ix++ {
+		x := series[ix].X
+		y := series[ix].Y
+		st += (y - ymean) * (y - ymean)
+		// guessing the compiler sees this is constant & does sth faster than exponentiation
+		sr += (y - (slope*x + intercept)) * (y - (slope*x + intercept))
+	}
+	stdError = (math.Sqrt((sr / (flen - 2.0)))) // todo: must check that min 2 points are supplied
+	correlation = (math.Sqrt(((st - sr) / st)))
+	return slope, intercept, stdError, correlation
+}
+
+// splits the series into the points with xmin <= X <= xmax and the rest
+func FilterXRange(series []Point, xmin, xmax float64) (kept, excluded []Point) {
+	kept = make([]Point, 0, len(series))
+	for _, pt := range series {
+		if pt.X >= xmin && pt.X <= xmax {
+			kept = append(kept, pt)
+		} else {
+			excluded = append(excluded, pt)
+		}
+	}
+	return kept, excluded
+}
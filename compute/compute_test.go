@@ -0,0 +1,110 @@
+package compute
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+// within a few ulps of want
+func closeTo(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-12*math.Max(1, math.Abs(want))
+}
+
+// the residuals are taken from slope*x + intercept; with the intercept
+// subtracted instead, any line off the origin got a wrong stdError and
+// correlation
+func TestLinearRegressionResiduals(t *testing.T) {
+	tests := []struct {
+		name                                    string
+		series                                  []Point
+		slope, intercept, stdError, correlation float64
+	}{
+		{"exact line off the origin", []Point{{X: 0, Y: 1}, {X: 1, Y: 3}, {X: 2, Y: 5}, {X: 3, Y: 7}}, 2, 1, 0, 1},
+		{"scattered", []Point{{X: 0, Y: 1}, {X: 1, Y: 3.5}, {X: 2, Y: 4.5}, {X: 3, Y: 7.5}}, 2.05, 1.05, 0.5809475019311126, 0.9843150312230544},
+	}
+	for _, test := range tests {
+		slope, intercept, stdError, correlation := LinearRegression(test.series)
+		if !closeTo(slope, test.slope) || !closeTo(intercept, test.intercept) ||
+			!closeTo(stdError, test.stdError) || !closeTo(correlation, test.correlation) {
+			t.Errorf("%s: got slope %v intercept %v stdError %v correlation %v, want %v %v %v %v", test.name,
+				slope, intercept, stdError, correlation, test.slope, test.intercept, test.stdError, test.correlation)
+		}
+	}
+}
+
+func TestFitDataSample(t *testing.T) {
+	for _, series := range [][]Point{nil, {{X: 1, Y: 2}, {X: 2, Y: 3}}, {{X: 1, Y: 2}, {X: 1, Y: 3}, {X: 1, Y: 4}}} {
+		var regressionError *RegressionError
+		if _, err := FitDataSample(series, Metadata{}); !errors.As(err, &regressionError) {
+			t.Errorf("%v: got %v, want a RegressionError", series, err)
+		}
+	}
+
+	// a flat line has no correlation, but must still encode
+	dataSample, err := FitDataSample([]Point{{X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}}, Metadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := dataSample.RegressionLine; line.Slope != 0 || line.Intercept != 2 || line.Correlation != 0 {
+		t.Errorf("got %+v, want y = 2 with correlation 0", line)
+	}
+	if _, err := json.Marshal(dataSample); err != nil {
+		t.Error(err)
+	}
+}
+
+// what the wasm computeRegression does with its data string
+func TestNewDataSampleFromText(t *testing.T) {
+	series, err := ParseSeries("1,3\n2,5\n3,7\n4,9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonDataSample, err := json.Marshal(NewDataSample(series, Metadata{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded struct {
+		Series         []Point        `json:"series"`
+		RegressionLine RegressionLine `json:"regressionLine"`
+	}
+	if err := json.Unmarshal(jsonDataSample, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Series) != 4 || !closeTo(decoded.RegressionLine.Slope, 2) || !closeTo(decoded.RegressionLine.Intercept, 1) {
+		t.Errorf("got %s, want 4 points on y = 2x + 1", jsonDataSample)
+	}
+}
+
+func TestLineCrossings(t *testing.T) {
+	// y = 2x - 6 crosses y=0 at x = 3; the mean point is (2, -2)
+	line := FitLine([]Point{{X: 0, Y: -6}, {X: 2, Y: -2}, {X: 4, Y: 2}})
+	if line.XInterceptUndefined || !closeTo(line.XIntercept, 3) || !closeTo(line.FittedAtMeanX, -2) {
+		t.Errorf("got x-intercept %v (undefined %v), fitted at mean x %v, want 3 and -2",
+			line.XIntercept, line.XInterceptUndefined, line.FittedAtMeanX)
+	}
+
+	// y = 5 never crosses y=0
+	line = FitLine([]Point{{X: 0, Y: 5}, {X: 1, Y: 5}, {X: 2, Y: 5}})
+	if !line.XInterceptUndefined || line.XIntercept != 0 || !closeTo(line.FittedAtMeanX, 5) {
+		t.Errorf("flat line: got x-intercept %v (undefined %v), fitted at mean x %v", line.XIntercept, line.XInterceptUndefined, line.FittedAtMeanX)
+	}
+}
+
+func TestFitLineWeightsByYErr(t *testing.T) {
+	// y = x measured precisely, and a point far off the line measured
+	// with a large error
+	series := []Point{{X: 0, Y: 0, YErr: 0.01}, {X: 1, Y: 1, YErr: 0.01}, {X: 2, Y: 2, YErr: 0.01},
+		{X: 3, Y: 3, YErr: 0.01}, {X: 4, Y: 100, YErr: 1000}}
+	line := FitLine(series)
+	if math.Abs(line.Slope-1) > 1e-6 || math.Abs(line.Intercept) > 1e-6 {
+		t.Errorf("weighted fit %v·x + %v, want x", line.Slope, line.Intercept)
+	}
+
+	// one point without an error: every point counts the same
+	series[0].YErr = 0
+	if line := FitLine(series); math.Abs(line.Slope-1) < 1 {
+		t.Errorf("unweighted slope %v, want pulled off by the outlier", line.Slope)
+	}
+}
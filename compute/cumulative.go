@@ -0,0 +1,45 @@
+package compute
+
+import "math"
+
+// the least squares line through the first N points of a series; nil fields
+// while those points all share one x, and RSquared nil while they share one y
+type CumulativeFit struct {
+	N         int      `json:"n"`
+	Slope     *float64 `json:"slope"`
+	Intercept *float64 `json:"intercept"`
+	RSquared  *float64 `json:"rSquared"`
+}
+
+// adds a point to the sums
+func (sums *RegressionSums) Add(pt Point) {
+	sums.N++
+	sums.SumX += pt.X
+	sums.SumY += pt.Y
+	sums.SumXY += pt.X * pt.Y
+	sums.SumX2 += pt.X * pt.X
+	sums.SumY2 += pt.Y * pt.Y
+}
+
+// fits the first k points for k = 2..len(series), one point added to the
+// running sums at a time
+func CumulativeFits(series []Point) []CumulativeFit {
+	fits := make([]CumulativeFit, 0, len(series))
+	var sums RegressionSums
+	for i, pt := range series {
+		sums.Add(pt)
+		if i == 0 {
+			continue
+		}
+		fit := CumulativeFit{N: sums.N}
+		if line, err := LineFromSums(sums); err == nil {
+			slope, intercept, rSquared := line.Slope, line.Intercept, line.Correlation*line.Correlation
+			fit.Slope, fit.Intercept = &slope, &intercept
+			if !math.IsNaN(rSquared) {
+				fit.RSquared = &rSquared
+			}
+		}
+		fits = append(fits, fit)
+	}
+	return fits
+}
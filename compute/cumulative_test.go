@@ -0,0 +1,33 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCumulativeFits(t *testing.T) {
+	// the first two points share an x, so there is no line through them
+	series := []Point{{X: 1, Y: 3}, {X: 1, Y: 5}, {X: 2, Y: 4.5}, {X: 4, Y: 9.2}, {X: 5, Y: 10.1}, {X: 7, Y: 15.3}}
+	fits := CumulativeFits(series)
+	if len(fits) != len(series)-1 {
+		t.Fatalf("got %d fits, want %d", len(fits), len(series)-1)
+	}
+	for k, fit := range fits {
+		if fit.N != k+2 {
+			t.Errorf("fit %d: n = %d, want %d", k, fit.N, k+2)
+		}
+	}
+	if fits[0].Slope != nil || fits[0].RSquared != nil {
+		t.Errorf("same x: got slope %v, r² %v", fits[0].Slope, fits[0].RSquared)
+	}
+
+	final := fits[len(fits)-1]
+	slope, intercept, _, correlation := LinearRegression(series)
+	if final.Slope == nil || final.RSquared == nil {
+		t.Fatalf("no final fit: %+v", final)
+	}
+	if math.Abs(*final.Slope-slope) > 1e-9 || math.Abs(*final.Intercept-intercept) > 1e-9 || math.Abs(*final.RSquared-correlation*correlation) > 1e-9 {
+		t.Errorf("final fit y = %vx + %v, r² %v; want y = %vx + %v, r² %v",
+			*final.Slope, *final.Intercept, *final.RSquared, slope, intercept, correlation*correlation)
+	}
+}
@@ -0,0 +1,44 @@
+package compute
+
+import "math"
+
+// point counts over a grid of equal cells spanning a series, for drawing
+// a heatmap when there are too many points to draw one by one
+type DensityGrid struct {
+	XMin    float64 `json:"xmin" xml:"xmin"`
+	XMax    float64 `json:"xmax" xml:"xmax"`
+	YMin    float64 `json:"ymin" xml:"ymin"`
+	YMax    float64 `json:"ymax" xml:"ymax"`
+	Columns int     `json:"columns" xml:"columns"`
+	Rows    int     `json:"rows" xml:"rows"`
+	// Counts[row][column], row 0 at YMin and column 0 at XMin
+	Counts   [][]int `json:"counts" xml:"counts>count"`
+	MaxCount int     `json:"maxCount" xml:"maxCount"`
+}
+
+// bins the series into a size by size grid. Points on the upper edges go
+// into the last row and column.
+func Density(series []Point, size int) *DensityGrid {
+	grid := &DensityGrid{Columns: size, Rows: size, Counts: make([][]int, size)}
+	for row := range grid.Counts {
+		grid.Counts[row] = make([]int, size)
+	}
+	if len(series) == 0 {
+		return grid
+	}
+	grid.XMin, grid.XMax, grid.YMin, grid.YMax = SeriesBounds(series)
+	cell := func(v, min, max float64) int {
+		if max == min {
+			return 0
+		}
+		return int(math.Min(float64(size-1), math.Floor((v-min)/(max-min)*float64(size))))
+	}
+	for _, pt := range series {
+		row, column := cell(pt.Y, grid.YMin, grid.YMax), cell(pt.X, grid.XMin, grid.XMax)
+		grid.Counts[row][column]++
+		if grid.Counts[row][column] > grid.MaxCount {
+			grid.MaxCount = grid.Counts[row][column]
+		}
+	}
+	return grid
+}
@@ -0,0 +1,31 @@
+package compute
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDensityCountsPerCell(t *testing.T) {
+	// 0..10 square in a 2 by 2 grid: 6 points bottom left, 3 top right,
+	// 1 bottom right, on the upper X edge
+	series := []Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 2}, {X: 3, Y: 1}, {X: 4, Y: 4}, {X: 1, Y: 4},
+		{X: 6, Y: 6}, {X: 9, Y: 9}, {X: 10, Y: 10}, {X: 10, Y: 0}}
+	grid := Density(series, 2)
+	if want := [][]int{{6, 1}, {0, 3}}; !reflect.DeepEqual(grid.Counts, want) {
+		t.Errorf("counts %v, want %v", grid.Counts, want)
+	}
+	if grid.MaxCount != 6 || grid.XMin != 0 || grid.XMax != 10 || grid.YMin != 0 || grid.YMax != 10 {
+		t.Errorf("got %+v", grid)
+	}
+
+	total := 0
+	grid = Density(series, 7)
+	for _, row := range grid.Counts {
+		for _, count := range row {
+			total += count
+		}
+	}
+	if total != len(series) || grid.Rows != 7 || grid.Columns != 7 {
+		t.Errorf("%d points in a %d by %d grid, want all %d in 7 by 7", total, grid.Rows, grid.Columns, len(series))
+	}
+}
@@ -0,0 +1,109 @@
+package compute
+
+import (
+	"strconv"
+	"strings"
+)
+
+// how many lines DetectFormat looks at
+const detectLines = 10
+
+// delimiters tried by DetectFormat, in order of preference on a tie
+var detectDelimiters = []string{",", ";", "\t", " "}
+
+// the layout of a pasted dataset, as guessed by DetectFormat
+type Format struct {
+	Envelope
+	Delimiter        string `json:"delimiter"`
+	DecimalSeparator string `json:"decimalSeparator"`
+	Columns          int    `json:"columns"`
+	Header           bool   `json:"header"`
+	LineEnding       string `json:"lineEnding"` // "CRLF" or "LF"
+}
+
+// guesses the delimiter, decimal separator, column count, header row and
+// line ending from the first lines of src. Every delimiter and decimal
+// separator pair is tried, and the one giving the most consistent column
+// count and the most numeric fields wins.
+func DetectFormat(src string) Format {
+	format := Format{Envelope: NewEnvelope(), Delimiter: ",", DecimalSeparator: ".", LineEnding: "LF"}
+	lines := make([]string, 0, detectLines)
+	for _, line := range strings.SplitAfter(src, "\n") {
+		if strings.HasSuffix(line, "\r\n") {
+			format.LineEnding = "CRLF"
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+		if len(lines) == detectLines {
+			break
+		}
+	}
+	if len(lines) == 0 {
+		return format
+	}
+
+	bestConsistent, bestNumeric := -1, -1
+	for _, delimiter := range detectDelimiters {
+		for _, decimal := range []string{".", ","} {
+			if decimal == delimiter {
+				continue
+			}
+			columns, consistent := modeColumns(lines, delimiter)
+			if columns < 2 {
+				continue
+			}
+			numeric := 0
+			for _, line := range lines[1:] {
+				numeric += numericFields(splitDelimited(line, delimiter), decimal)
+			}
+			if consistent > bestConsistent || consistent == bestConsistent && numeric > bestNumeric {
+				bestConsistent, bestNumeric = consistent, numeric
+				format.Delimiter, format.DecimalSeparator, format.Columns = delimiter, decimal, columns
+			}
+		}
+	}
+	// a first line of words above lines of numbers is a header
+	first := splitDelimited(lines[0], format.Delimiter)
+	format.Header = len(lines) > 1 && numericFields(first, format.DecimalSeparator) < len(first) &&
+		bestNumeric > 0
+	return format
+}
+
+func splitDelimited(line, delimiter string) []string {
+	if delimiter == " " {
+		return strings.Fields(line)
+	}
+	return strings.Split(line, delimiter)
+}
+
+// the most common column count and the number of lines having it
+func modeColumns(lines []string, delimiter string) (columns, count int) {
+	counts := make(map[int]int)
+	for _, line := range lines {
+		counts[len(splitDelimited(line, delimiter))]++
+	}
+	for c, n := range counts {
+		if n > count || n == count && c > columns {
+			columns, count = c, n
+		}
+	}
+	return columns, count
+}
+
+func numericFields(fields []string, decimal string) (numeric int) {
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if decimal == "," {
+			// a "." is not a valid number with comma decimals
+			if strings.Contains(field, ".") {
+				continue
+			}
+			field = strings.Replace(field, ",", ".", 1)
+		}
+		if _, err := strconv.ParseFloat(field, 64); err == nil {
+			numeric++
+		}
+	}
+	return numeric
+}
@@ -0,0 +1,28 @@
+package compute
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name               string
+		src                string
+		delimiter, decimal string
+		columns            int
+		header             bool
+		lineEnding         string
+	}{
+		{"German CSV", "Zeit;Temperatur;Druck\r\n1,5;20,25;1013,2\r\n2,5;20,75;1012,8\r\n3,5;21,5;1012,1\r\n",
+			";", ",", 3, true, "CRLF"},
+		{"plain CSV", "1.5,2\n2.5,4\n3.5,6\n", ",", ".", 2, false, "LF"},
+		{"tab separated", "x\ty\n1\t2\n2\t4\n", "\t", ".", 2, true, "LF"},
+	}
+	for _, test := range tests {
+		format := DetectFormat(test.src)
+		if format.Delimiter != test.delimiter || format.DecimalSeparator != test.decimal || format.Columns != test.columns ||
+			format.Header != test.header || format.LineEnding != test.lineEnding {
+			t.Errorf("%s: got %q %q %d header %v %s, want %q %q %d header %v %s", test.name,
+				format.Delimiter, format.DecimalSeparator, format.Columns, format.Header, format.LineEnding,
+				test.delimiter, test.decimal, test.columns, test.header, test.lineEnding)
+		}
+	}
+}
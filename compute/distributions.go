@@ -0,0 +1,147 @@
+package compute
+
+import "math"
+
+// regularized incomplete beta function I_x(a, b)
+// based on Numerical Recipes in C, 2nd ed., section 6.4
+func RegIncBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lga, _ := math.Lgamma(a)
+	lgb, _ := math.Lgamma(b)
+	lgab, _ := math.Lgamma(a + b)
+	front := math.Exp(lgab - lga - lgb + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges quickly only on this side
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+// Lentz's method for the incomplete beta continued fraction
+func betaContinuedFraction(x, a, b float64) float64 {
+	const maxIterations = 300
+	const epsilon = 1e-14
+	const tiny = 1e-300
+	qab, qap, qam := a+b, a+1, a-1
+	c, d := 1.0, 1-qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1; m <= maxIterations; m++ {
+		fm := float64(m)
+		m2 := 2 * fm
+		aa := fm * (b - fm) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + fm) * (qab + fm) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+// cumulative distribution function of the F distribution
+func FCDF(f, d1, d2 float64) float64 {
+	if f <= 0 {
+		return 0
+	}
+	return RegIncBeta(d1*f/(d1*f+d2), d1/2, d2/2)
+}
+
+// cumulative distribution function of Student's t distribution
+func TCDF(t, df float64) float64 {
+	tail := RegIncBeta(df/(df+t*t), df/2, 0.5) / 2
+	if t < 0 {
+		return tail
+	}
+	return 1 - tail
+}
+
+// the t for which TCDF(t, df) = p, found by bisection
+func TQuantile(p, df float64) float64 {
+	lo, hi := -1.0, 1.0
+	for TCDF(lo, df) > p {
+		lo *= 2
+	}
+	for TCDF(hi, df) < p {
+		hi *= 2
+	}
+	for i := 0; i < 100 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if TCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// the f for which FCDF(f, d1, d2) = p, found by bisection
+func FQuantile(p, d1, d2 float64) float64 {
+	lo, hi := 0.0, 1.0
+	for FCDF(hi, d1, d2) < p {
+		hi *= 2
+	}
+	for i := 0; i < 200 && hi-lo > 1e-12*hi; i++ {
+		mid := (lo + hi) / 2
+		if FCDF(mid, d1, d2) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// cumulative distribution function of the noncentral F distribution with
+// noncentrality lambda, as a Poisson weighted sum of central F terms
+func NoncentralFCDF(f, d1, d2, lambda float64) float64 {
+	if f <= 0 {
+		return 0
+	}
+	x := d1 * f / (d1*f + d2)
+	half := lambda / 2
+	// the Poisson weights are negligible this far past their mean
+	last := int(half + 12*math.Sqrt(half) + 50)
+	sum := 0.0
+	for j := 0; j <= last; j++ {
+		lgj, _ := math.Lgamma(float64(j) + 1)
+		weight := math.Exp(-half + float64(j)*math.Log(half) - lgj)
+		if half == 0 {
+			weight = 0
+			if j == 0 {
+				weight = 1
+			}
+		}
+		sum += weight * RegIncBeta(x, d1/2+float64(j), d2/2)
+	}
+	return sum
+}
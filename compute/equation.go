@@ -0,0 +1,89 @@
+package compute
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// significant digits of the numbers in RegressionLine.Equation, set by the
+// server from Config.EquationPrecision
+var EquationPrecision = 3
+
+// coefficients smaller than this are left out of equations
+const equationEpsilon = 1e-10
+
+var superscripts = strings.NewReplacer("0", "⁰", "1", "¹", "2", "²", "3", "³",
+	"4", "⁴", "5", "⁵", "6", "⁶", "7", "⁷", "8", "⁸", "9", "⁹")
+
+// the equation of a fitted model followed by its R², for display:
+// "y = 2.13x + 0.48 (R² = 0.97)"
+func lineEquation(regressionType string, coefficients []float64, correlation float64) string {
+	equation := formatEquation(regressionType, coefficients, EquationPrecision)
+	if math.IsNaN(correlation) || math.IsInf(correlation, 0) {
+		return equation
+	}
+	return equation + " (R² = " + strconv.FormatFloat(correlation*correlation, 'g', EquationPrecision, 64) + ")"
+}
+
+// writes a fitted model as a human readable equation, "y = 2.34x - 1.56".
+// Linear and polynomial coefficients are in ascending powers of x;
+// exponential ones are a, b of y = a·e^(bx), logarithmic ones a, b of
+// y = a + b·ln(x) and power law ones a, b of y = a·x^b.
+func formatEquation(regressionType string, coefficients []float64, precision int) string {
+	number := func(v float64) string {
+		return strconv.FormatFloat(v, 'g', precision, 64)
+	}
+	if regressionType == "exponential" {
+		if len(coefficients) < 2 {
+			return ""
+		}
+		return "y = " + number(coefficients[0]) + "·e^(" + number(coefficients[1]) + "x)"
+	}
+	if regressionType == "logarithmic" {
+		if len(coefficients) < 2 {
+			return ""
+		}
+		sign := " + "
+		if coefficients[1] < 0 {
+			sign = " - "
+		}
+		return "y = " + number(coefficients[0]) + sign + number(math.Abs(coefficients[1])) + "·ln(x)"
+	}
+	if regressionType == "powerlaw" {
+		if len(coefficients) < 2 {
+			return ""
+		}
+		return "y = " + number(coefficients[0]) + "·x^" + number(coefficients[1])
+	}
+
+	var equation strings.Builder
+	equation.WriteString("y =")
+	for power := len(coefficients) - 1; power >= 0; power-- {
+		c := coefficients[power]
+		if math.Abs(c) < equationEpsilon {
+			continue
+		}
+		switch {
+		case c < 0 && equation.Len() == 3:
+			equation.WriteString(" -")
+		case c < 0:
+			equation.WriteString(" - ")
+		case equation.Len() > 3:
+			equation.WriteString(" + ")
+		default:
+			equation.WriteString(" ")
+		}
+		equation.WriteString(number(math.Abs(c)))
+		if power > 0 {
+			equation.WriteString("x")
+		}
+		if power > 1 {
+			equation.WriteString(superscripts.Replace(strconv.Itoa(power)))
+		}
+	}
+	if equation.Len() == 3 {
+		equation.WriteString(" 0")
+	}
+	return equation.String()
+}
@@ -0,0 +1,35 @@
+package compute
+
+import "testing"
+
+func TestFormatEquation(t *testing.T) {
+	tests := []struct {
+		regressionType string
+		coefficients   []float64
+		want           string
+	}{
+		{"linear", []float64{1.56, 2.34}, "y = 2.34x + 1.56"},
+		{"linear", []float64{-1.56, 2.34}, "y = 2.34x - 1.56"},
+		{"linear", []float64{1.56, -2.34}, "y = -2.34x + 1.56"},
+		{"linear", []float64{1e-12, 2.34}, "y = 2.34x"},
+		{"linear", []float64{1.56, -1e-11}, "y = 1.56"},
+		{"linear", []float64{0, 0}, "y = 0"},
+		{"polynomial", []float64{1.56, 2.34, 0.78}, "y = 0.78x² + 2.34x + 1.56"},
+		{"polynomial", []float64{-1, 0, -0.5, 1e-13, 2}, "y = 2x⁴ - 0.5x² - 1"},
+		{"polynomial", []float64{0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3}, "y = 3x¹¹ + 1x"},
+		{"exponential", []float64{3.21, 0.45}, "y = 3.21·e^(0.45x)"},
+		{"exponential", []float64{3.21, -0.45}, "y = 3.21·e^(-0.45x)"},
+		{"logarithmic", []float64{1.5, 0.25}, "y = 1.5 + 0.25·ln(x)"},
+		{"logarithmic", []float64{1.5, -0.25}, "y = 1.5 - 0.25·ln(x)"},
+		{"powerlaw", []float64{2, 1.5}, "y = 2·x^1.5"},
+		{"powerlaw", []float64{2, -0.5}, "y = 2·x^-0.5"},
+	}
+	for _, test := range tests {
+		if got := formatEquation(test.regressionType, test.coefficients, 3); got != test.want {
+			t.Errorf("%s %v: got %q, want %q", test.regressionType, test.coefficients, got, test.want)
+		}
+	}
+	if got := formatEquation("linear", []float64{1, 2.3456789}, 5); got != "y = 2.3457x + 1" {
+		t.Errorf("at 5 digits: got %q", got)
+	}
+}
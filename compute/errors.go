@@ -0,0 +1,32 @@
+package compute
+
+import (
+	"fmt"
+)
+
+// input that couldn't be read as a data series
+type ParseError struct {
+	Line int // 1-based, 0 when the problem isn't with a particular line
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Err.Error())
+	}
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// a fit that can't be computed from the data it was given
+type RegressionError struct {
+	Model string
+	Err   error
+}
+
+func (e *RegressionError) Error() string {
+	return e.Model + ": " + e.Err.Error()
+}
+
+func (e *RegressionError) Unwrap() error { return e.Err }
@@ -0,0 +1,64 @@
+package compute
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrBadFilter = errors.New(`a filter is x or y, a comparison and a number, like "y>0"`)
+
+// the comparisons a filter can make, longest first so that ">=" isn't read
+// as ">"
+var filterComparisons = []struct {
+	op      string
+	compare func(v, bound float64) bool
+}{
+	{">=", func(v, bound float64) bool { return v >= bound }},
+	{"<=", func(v, bound float64) bool { return v <= bound }},
+	{"==", func(v, bound float64) bool { return v == bound }},
+	{"!=", func(v, bound float64) bool { return v != bound }},
+	{">", func(v, bound float64) bool { return v > bound }},
+	{"<", func(v, bound float64) bool { return v < bound }},
+}
+
+// parses a point filter comparing x or y to a number, like "y>0" or
+// "x <= 10"
+func ParseFilter(expr string) (keep func(Point) bool, err error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, ErrBadFilter
+	}
+	var coord func(Point) float64
+	switch expr[0] {
+	case 'x':
+		coord = func(pt Point) float64 { return pt.X }
+	case 'y':
+		coord = func(pt Point) float64 { return pt.Y }
+	default:
+		return nil, ErrBadFilter
+	}
+	rest := strings.TrimSpace(expr[1:])
+	for _, comparison := range filterComparisons {
+		if !strings.HasPrefix(rest, comparison.op) {
+			continue
+		}
+		bound, err := parseNumber(rest[len(comparison.op):])
+		if err != nil {
+			return nil, ErrBadFilter
+		}
+		compare := comparison.compare
+		return func(pt Point) bool { return compare(coord(pt), bound) }, nil
+	}
+	return nil, ErrBadFilter
+}
+
+// the points of the series keep is true for
+func FilterPoints(series []Point, keep func(Point) bool) []Point {
+	kept := make([]Point, 0, len(series))
+	for _, pt := range series {
+		if keep(pt) {
+			kept = append(kept, pt)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,47 @@
+package compute
+
+import "sort"
+
+// the step between forecast x values: the median gap between neighbouring
+// distinct x values, which is the spacing of evenly spaced data and isn't
+// thrown off by the odd missing or extra sample otherwise
+func ForecastStep(series []Point) (float64, error) {
+	xs := make([]float64, len(series))
+	for i, pt := range series {
+		xs[i] = pt.X
+	}
+	sort.Float64s(xs)
+	gaps := make([]float64, 0, len(xs))
+	for i := 1; i < len(xs); i++ {
+		if gap := xs[i] - xs[i-1]; gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 0, &RegressionError{Model: "ols", Err: ErrSameX}
+	}
+	sort.Float64s(gaps)
+	middle := len(gaps) / 2
+	if len(gaps)%2 == 0 {
+		return (gaps[middle-1] + gaps[middle]) / 2, nil
+	}
+	return gaps[middle], nil
+}
+
+// the x values steps of ForecastStep beyond the largest x of the series
+func ForecastXs(series []Point, steps int) (xs []float64, step float64, err error) {
+	if step, err = ForecastStep(series); err != nil {
+		return nil, 0, err
+	}
+	maxX := series[0].X
+	for _, pt := range series {
+		if pt.X > maxX {
+			maxX = pt.X
+		}
+	}
+	xs = make([]float64, steps)
+	for i := range xs {
+		xs[i] = maxX + float64(i+1)*step
+	}
+	return xs, step, nil
+}
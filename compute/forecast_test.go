@@ -0,0 +1,34 @@
+package compute
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestForecastXs(t *testing.T) {
+	tests := []struct {
+		xs   []float64
+		want []float64
+		step float64
+	}{
+		{[]float64{0, 1, 2, 3}, []float64{4, 5}, 1},
+		// a missing sample and a repeated x don't change the spacing
+		{[]float64{3, 0, 1, 1, 2, 5, 6}, []float64{7, 8}, 1},
+		// an even number of gaps, 1, 2, 3 and 4
+		{[]float64{0, 1, 3, 6, 10}, []float64{12.5, 15}, 2.5},
+	}
+	for _, test := range tests {
+		series := make([]Point, len(test.xs))
+		for i, x := range test.xs {
+			series[i] = Point{X: x}
+		}
+		xs, step, err := ForecastXs(series, 2)
+		if err != nil || step != test.step || !reflect.DeepEqual(xs, test.want) {
+			t.Errorf("%v: got %v by %v, %v; want %v by %v", test.xs, xs, step, err, test.want, test.step)
+		}
+	}
+	if _, _, err := ForecastXs([]Point{{X: 1, Y: 1}, {X: 1, Y: 2}}, 2); !errors.Is(err, ErrSameX) {
+		t.Errorf("one x: got %v, want %v", err, ErrSameX)
+	}
+}
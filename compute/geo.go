@@ -0,0 +1,124 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// mean Earth radius in km
+const earthRadius = 6371.0
+
+var ErrNotGeographic = errors.New("coordinates out of range for WGS84")
+
+// extent of a geographic series, in degrees
+type GeoBounds struct {
+	MinLat float64 `json:"minLat" xml:"minLat"`
+	MaxLat float64 `json:"maxLat" xml:"maxLat"`
+	MinLon float64 `json:"minLon" xml:"minLon"`
+	MaxLon float64 `json:"maxLon" xml:"maxLon"`
+}
+
+// geographic description of a series, areas in km² and distances in km
+type Geo struct {
+	CentroidLat        float64 `json:"centroidLat" xml:"centroidLat"`
+	CentroidLon        float64 `json:"centroidLon" xml:"centroidLon"`
+	BoundingBoxAreaKm2 float64 `json:"boundingBoxAreaKm2" xml:"boundingBoxAreaKm2"`
+	ConvexHullAreaKm2  float64 `json:"convexHullAreaKm2" xml:"convexHullAreaKm2"`
+	MaxDistanceKm      float64 `json:"maxDistanceKm" xml:"maxDistanceKm"`
+}
+
+// treats x as longitude and y as latitude and describes the area covered
+func GeoSummary(series []Point) (*GeoBounds, *Geo, error) {
+	if len(series) == 0 {
+		return nil, nil, ErrNotGeographic
+	}
+	bounds := &GeoBounds{MinLat: 90, MaxLat: -90, MinLon: 180, MaxLon: -180}
+	// centroid of the unit vectors, which copes with the antimeridian
+	var cx, cy, cz float64
+	for _, pt := range series {
+		lon, lat := pt.X, pt.Y
+		if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			return nil, nil, ErrNotGeographic
+		}
+		bounds.MinLat = math.Min(bounds.MinLat, lat)
+		bounds.MaxLat = math.Max(bounds.MaxLat, lat)
+		bounds.MinLon = math.Min(bounds.MinLon, lon)
+		bounds.MaxLon = math.Max(bounds.MaxLon, lon)
+		phi, lambda := radians(lat), radians(lon)
+		cx += math.Cos(phi) * math.Cos(lambda)
+		cy += math.Cos(phi) * math.Sin(lambda)
+		cz += math.Sin(phi)
+	}
+
+	geo := &Geo{CentroidLat: degrees(math.Atan2(cz, math.Hypot(cx, cy))),
+		CentroidLon: degrees(math.Atan2(cy, cx))}
+	// exact area of a latitude/longitude rectangle on the sphere
+	geo.BoundingBoxAreaKm2 = earthRadius * earthRadius *
+		radians(bounds.MaxLon-bounds.MinLon) *
+		(math.Sin(radians(bounds.MaxLat)) - math.Sin(radians(bounds.MinLat)))
+
+	hull := ConvexHull(series)
+	// shoelace formula on an equirectangular projection around the centroid
+	scale := math.Cos(radians(geo.CentroidLat))
+	area := 0.0
+	for i := range hull {
+		a, b := hull[i], hull[(i+1)%len(hull)]
+		area += radians(a.X)*scale*radians(b.Y) - radians(b.X)*scale*radians(a.Y)
+	}
+	geo.ConvexHullAreaKm2 = math.Abs(area) / 2 * earthRadius * earthRadius
+
+	// the farthest pair of points is always on the hull
+	for i := range hull {
+		for j := i + 1; j < len(hull); j++ {
+			d := Haversine(hull[i].Y, hull[i].X, hull[j].Y, hull[j].X)
+			geo.MaxDistanceKm = math.Max(geo.MaxDistanceKm, d)
+		}
+	}
+	return bounds, geo, nil
+}
+
+// great-circle distance in km between two lat/lon pairs given in degrees
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	dphi := radians(lat2 - lat1)
+	dlambda := radians(lon2 - lon1)
+	a := math.Sin(dphi/2)*math.Sin(dphi/2) +
+		math.Cos(radians(lat1))*math.Cos(radians(lat2))*math.Sin(dlambda/2)*math.Sin(dlambda/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// convex hull of the points in counter-clockwise order (Andrew's monotone chain)
+func ConvexHull(series []Point) []Point {
+	points := make([]Point, len(series))
+	copy(points, series)
+	sort.Slice(points, func(i, j int) bool {
+		if points[i].X == points[j].X {
+			return points[i].Y < points[j].Y
+		}
+		return points[i].X < points[j].X
+	})
+	if len(points) < 3 {
+		return points
+	}
+	cross := func(o, a, b Point) float64 {
+		return (a.X-o.X)*(b.Y-o.Y) - (a.Y-o.Y)*(b.X-o.X)
+	}
+	hull := make([]Point, 0, 2*len(points))
+	for _, pt := range points {
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], pt) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, pt)
+	}
+	lower := len(hull) + 1
+	for i := len(points) - 2; i >= 0; i-- {
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], points[i]) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, points[i])
+	}
+	return hull[:len(hull)-1]
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
@@ -0,0 +1,25 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		km                     float64
+	}{
+		{"quarter of the equator", 0, 0, 0, 90, math.Pi / 2 * earthRadius},
+		{"pole to pole", 90, 0, -90, 0, math.Pi * earthRadius},
+		{"Paris to London", 48.8566, 2.3522, 51.5074, -0.1278, 343.556},
+		{"JFK to Heathrow", 40.6413, -73.7781, 51.4700, -0.4543, 5540.011},
+		{"same place", 12.5, 45, 12.5, 45, 0},
+	}
+	for _, test := range tests {
+		if got := Haversine(test.lat1, test.lon1, test.lat2, test.lon2); math.Abs(got-test.km) > 0.001 {
+			t.Errorf("%s: got %v km, want %v", test.name, got, test.km)
+		}
+	}
+}
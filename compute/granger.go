@@ -0,0 +1,105 @@
+package compute
+
+import (
+	"errors"
+	"sort"
+)
+
+type GrangerResult struct {
+	Envelope
+	Lags          int     `json:"lags"`
+	FStatistic    float64 `json:"fStatistic"`
+	PValue        float64 `json:"pValue"`
+	GrangerCauses bool    `json:"granger_causes"`
+}
+
+// tests whether the past of cause improves the prediction of effect beyond
+// effect's own past. Both series are ordered by X and taken as equally
+// spaced; the test is significant at the 0.05 level.
+func GrangerCausality(effect, cause []Point, lags int) (*GrangerResult, error) {
+	if len(effect) != len(cause) {
+		return nil, &RegressionError{Model: "granger", Err: ErrUnmatchedSeries}
+	}
+	y, x := sortedYs(effect), sortedYs(cause)
+	// observations usable once the first lags values are used up, and the
+	// residual degrees of freedom of the unrestricted model
+	n := len(y) - lags
+	df := n - 2*lags - 1
+	if lags < 1 || df < 1 {
+		return nil, &RegressionError{Model: "granger", Err: errors.New("not enough points for the number of lags")}
+	}
+
+	restricted := make([][]float64, n)
+	unrestricted := make([][]float64, n)
+	target := make([]float64, n)
+	for t := lags; t < len(y); t++ {
+		row := []float64{1}
+		for i := 1; i <= lags; i++ {
+			row = append(row, y[t-i])
+		}
+		restricted[t-lags] = row
+		full := append([]float64(nil), row...)
+		for i := 1; i <= lags; i++ {
+			full = append(full, x[t-i])
+		}
+		unrestricted[t-lags] = full
+		target[t-lags] = y[t]
+	}
+
+	_, rssR, err := leastSquares(restricted, target)
+	if err != nil {
+		return nil, &RegressionError{Model: "granger", Err: err}
+	}
+	_, rssU, err := leastSquares(unrestricted, target)
+	if err != nil {
+		return nil, &RegressionError{Model: "granger", Err: err}
+	}
+
+	result := &GrangerResult{Envelope: NewEnvelope(), Lags: lags}
+	result.FStatistic = ((rssR - rssU) / float64(lags)) / (rssU / float64(df))
+	result.PValue = 1 - FCDF(result.FStatistic, float64(lags), float64(df))
+	result.GrangerCauses = result.PValue < 0.05
+	return result, nil
+}
+
+// ordinary least squares of y on the rows of the design matrix, through the
+// normal equations. Returns the coefficients and the residual sum of squares.
+func leastSquares(design [][]float64, y []float64) (coefficients []float64, rss float64, err error) {
+	k := len(design[0])
+	xtx := make([][]float64, k)
+	for i := range xtx {
+		xtx[i] = make([]float64, k)
+	}
+	xty := make([]float64, k)
+	for r, row := range design {
+		for i := 0; i < k; i++ {
+			xty[i] += row[i] * y[r]
+			for j := 0; j < k; j++ {
+				xtx[i][j] += row[i] * row[j]
+			}
+		}
+	}
+	if coefficients, err = SolveLinearSystem(xtx, xty); err != nil {
+		return nil, 0, err
+	}
+	for r, row := range design {
+		fitted := 0.0
+		for i, c := range coefficients {
+			fitted += c * row[i]
+		}
+		rss += (y[r] - fitted) * (y[r] - fitted)
+	}
+	return coefficients, rss, nil
+}
+
+// the Y values of the series in order of X
+func sortedYs(series []Point) []float64 {
+	sorted := make([]Point, len(series))
+	copy(sorted, series)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	ys := make([]float64, len(sorted))
+	for i, pt := range sorted {
+		ys[i] = pt.Y
+	}
+	return ys
+}
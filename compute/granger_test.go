@@ -0,0 +1,43 @@
+package compute
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGrangerCausalityDelayedCopy(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cause := make([]Point, 100)
+	effect := make([]Point, 100)
+	for i := range cause {
+		cause[i] = Point{X: float64(i), Y: rng.NormFloat64()}
+		// cause, one step later, with a little noise of its own
+		effect[i] = Point{X: float64(i), Y: 0.1 * rng.NormFloat64()}
+		if i > 0 {
+			effect[i].Y += cause[i-1].Y
+		}
+	}
+
+	result, err := GrangerCausality(effect, cause, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.GrangerCauses || result.PValue >= 0.05 {
+		t.Errorf("the delayed copy: got F %v p %v, want Granger-causal", result.FStatistic, result.PValue)
+	}
+	// the copy doesn't say anything about the white noise it follows
+	result, err = GrangerCausality(cause, effect, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.GrangerCauses {
+		t.Errorf("the other way round: got F %v p %v, want not Granger-causal", result.FStatistic, result.PValue)
+	}
+
+	if _, err := GrangerCausality(effect, cause[:50], 2); err == nil {
+		t.Error("series of different lengths were accepted")
+	}
+	if _, err := GrangerCausality(effect[:5], cause[:5], 2); err == nil {
+		t.Error("5 points were enough for 2 lags")
+	}
+}
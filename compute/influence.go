@@ -0,0 +1,56 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+// how much a point pulls the least squares line
+type Influence struct {
+	// the hat value, how far the point's x is from the others'
+	Leverage float64 `json:"leverage" xml:"leverage"`
+	// how far the fit moves without the point, scaled by the residual
+	// variance; nil when that is undefined, for a point that alone fixes
+	// the line or a series the line fits exactly
+	CooksDistance *float64 `json:"cooksDistance" xml:"cooksDistance"`
+}
+
+// the leverage and Cook's distance of each point for the least squares
+// line. It needs at least 3 points and more than one distinct x.
+func InfluenceOf(series []Point) ([]Influence, error) {
+	n := float64(len(series))
+	if len(series) < 3 {
+		return nil, &RegressionError{Model: "linear", Err: errors.New("at least 3 points are needed for influence")}
+	}
+	xmean := 0.0
+	for _, pt := range series {
+		xmean += pt.X / n
+	}
+	sxx := 0.0
+	for _, pt := range series {
+		sxx += (pt.X - xmean) * (pt.X - xmean)
+	}
+	if sxx == 0 {
+		return nil, &RegressionError{Model: "linear", Err: ErrSameX}
+	}
+	slope, intercept, _, _ := LinearRegression(series)
+	sr := 0.0
+	residuals := make([]float64, len(series))
+	for i, pt := range series {
+		residuals[i] = pt.Y - (slope*pt.X + intercept)
+		sr += residuals[i] * residuals[i]
+	}
+	// residual variance of the two parameter fit
+	s2 := sr / (n - 2)
+
+	influence := make([]Influence, len(series))
+	for i, pt := range series {
+		h := 1/n + (pt.X-xmean)*(pt.X-xmean)/sxx
+		influence[i].Leverage = h
+		d := residuals[i] * residuals[i] / (2 * s2) * h / ((1 - h) * (1 - h))
+		if !math.IsNaN(d) && !math.IsInf(d, 0) {
+			influence[i].CooksDistance = &d
+		}
+	}
+	return influence, nil
+}
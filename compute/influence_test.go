@@ -0,0 +1,52 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestInfluenceOf(t *testing.T) {
+	// a loose line, and one point far out along x and well off it
+	series := []Point{{X: 1, Y: 1.2}, {X: 2, Y: 1.9}, {X: 3, Y: 3.1}, {X: 4, Y: 3.8},
+		{X: 5, Y: 5.1}, {X: 6, Y: 6.2}, {X: 7, Y: 6.9}, {X: 8, Y: 8.1}, {X: 30, Y: 5}}
+	influence, err := InfluenceOf(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outlier := influence[len(series)-1]
+	if outlier.Leverage < 0.8 || outlier.CooksDistance == nil || *outlier.CooksDistance < 1 {
+		t.Errorf("outlier: leverage %v, Cook's distance %v", outlier.Leverage, outlier.CooksDistance)
+	}
+	// the hat values of a line sum to its 2 parameters
+	sum := 0.0
+	for i, point := range influence {
+		sum += point.Leverage
+		if i < len(series)-1 && (point.CooksDistance == nil || *point.CooksDistance >= *outlier.CooksDistance) {
+			t.Errorf("point %d: Cook's distance %v, outlier's %v", i, point.CooksDistance, *outlier.CooksDistance)
+		}
+	}
+	if math.Abs(sum-2) > 1e-9 {
+		t.Errorf("leverages sum to %v, want 2", sum)
+	}
+}
+
+func TestInfluenceOfDegenerate(t *testing.T) {
+	var regressionError *RegressionError
+	if _, err := InfluenceOf([]Point{{X: 1, Y: 1}, {X: 1, Y: 2}, {X: 1, Y: 3}}); !errors.As(err, &regressionError) || !errors.Is(err, ErrSameX) {
+		t.Errorf("one x: got %v", err)
+	}
+	if _, err := InfluenceOf([]Point{{X: 1, Y: 1}, {X: 2, Y: 2}}); !errors.As(err, &regressionError) {
+		t.Errorf("2 points: got %v", err)
+	}
+	// no residual variance to scale by
+	influence, err := InfluenceOf([]Point{{X: 0, Y: 1}, {X: 1, Y: 3}, {X: 2, Y: 5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, point := range influence {
+		if point.CooksDistance != nil {
+			t.Errorf("exact line, point %d: Cook's distance %v", i, *point.CooksDistance)
+		}
+	}
+}
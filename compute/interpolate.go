@@ -0,0 +1,134 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// interpolation methods by name, each giving the values at xs of a series
+// sorted by X with distinct X values
+var InterpolationMethods = map[string]func(series []Point, xs []float64) []Point{
+	"linear":  interpolateLinear,
+	"cubic":   interpolateCubic,
+	"nearest": interpolateNearest,
+}
+
+// most points Interpolate returns
+const MaxInterpolatedPoints = MAXLINES
+
+var ErrTooManyPoints = errors.New("step too small for the X range")
+
+// resamples the series at minX, minX+step, ... up to maxX with the given
+// method. A step beyond the X range leaves just the first point. Points
+// sharing an X are averaged first.
+func Interpolate(series []Point, step float64, method func([]Point, []float64) []Point) ([]Point, error) {
+	sorted := distinctX(series)
+	if len(sorted) == 0 {
+		return make([]Point, 0), nil
+	}
+	minX, maxX := sorted[0].X, sorted[len(sorted)-1].X
+	count := math.Floor((maxX-minX)/step+1e-9) + 1
+	if count > MaxInterpolatedPoints {
+		return nil, ErrTooManyPoints
+	}
+	xs := make([]float64, int(count))
+	for i := range xs {
+		xs[i] = minX + float64(i)*step
+	}
+	return method(sorted, xs), nil
+}
+
+// a copy of the series ordered by X, with the Y of points sharing an X
+// averaged
+func distinctX(series []Point) []Point {
+	sorted := make([]Point, len(series))
+	copy(sorted, series)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	distinct := make([]Point, 0, len(sorted))
+	for i := 0; i < len(sorted); {
+		j, sum := i, 0.0
+		for ; j < len(sorted) && sorted[j].X == sorted[i].X; j++ {
+			sum += sorted[j].Y
+		}
+		distinct = append(distinct, Point{X: sorted[i].X, Y: sum / float64(j-i)})
+		i = j
+	}
+	return distinct
+}
+
+// index of the last point with X <= x, clamped so that i+1 is a point too
+func segment(series []Point, x float64) int {
+	i := sort.Search(len(series), func(i int) bool { return series[i].X > x }) - 1
+	return int(math.Max(0, math.Min(float64(i), float64(len(series)-2))))
+}
+
+// joins neighbouring points with straight lines
+func interpolateLinear(series []Point, xs []float64) []Point {
+	points := make([]Point, len(xs))
+	for k, x := range xs {
+		if len(series) == 1 {
+			points[k] = Point{X: x, Y: series[0].Y}
+			continue
+		}
+		i := segment(series, x)
+		a, b := series[i], series[i+1]
+		points[k] = Point{X: x, Y: a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)}
+	}
+	return points
+}
+
+// natural cubic spline through the points, the second derivatives being
+// solved from the tridiagonal system with the Thomas algorithm
+func interpolateCubic(series []Point, xs []float64) []Point {
+	n := len(series)
+	if n < 3 {
+		return interpolateLinear(series, xs)
+	}
+	h := make([]float64, n-1)
+	for i := range h {
+		h[i] = series[i+1].X - series[i].X
+	}
+	// m are the second derivatives, 0 at both ends for a natural spline
+	m := make([]float64, n)
+	sub, diag, super, rhs := make([]float64, n), make([]float64, n), make([]float64, n), make([]float64, n)
+	for i := 1; i < n-1; i++ {
+		sub[i], diag[i], super[i] = h[i-1], 2*(h[i-1]+h[i]), h[i]
+		rhs[i] = 6 * ((series[i+1].Y-series[i].Y)/h[i] - (series[i].Y-series[i-1].Y)/h[i-1])
+	}
+	for i := 2; i < n-1; i++ {
+		w := sub[i] / diag[i-1]
+		diag[i] -= w * super[i-1]
+		rhs[i] -= w * rhs[i-1]
+	}
+	for i := n - 2; i >= 1; i-- {
+		m[i] = (rhs[i] - super[i]*m[i+1]) / diag[i]
+	}
+
+	points := make([]Point, len(xs))
+	for k, x := range xs {
+		i := segment(series, x)
+		t0, t1 := series[i+1].X-x, x-series[i].X
+		y := m[i]*t0*t0*t0/(6*h[i]) + m[i+1]*t1*t1*t1/(6*h[i]) +
+			(series[i].Y/h[i]-m[i]*h[i]/6)*t0 + (series[i+1].Y/h[i]-m[i+1]*h[i]/6)*t1
+		points[k] = Point{X: x, Y: y}
+	}
+	return points
+}
+
+// takes the Y of the closest point, the lower one on a tie
+func interpolateNearest(series []Point, xs []float64) []Point {
+	points := make([]Point, len(xs))
+	for k, x := range xs {
+		if len(series) == 1 {
+			points[k] = Point{X: x, Y: series[0].Y}
+			continue
+		}
+		i := segment(series, x)
+		if x-series[i].X > series[i+1].X-x {
+			i++
+		}
+		points[k] = Point{X: x, Y: series[i].Y}
+	}
+	return points
+}
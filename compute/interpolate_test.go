@@ -0,0 +1,46 @@
+package compute
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolateLinearMidpoints(t *testing.T) {
+	// out of order, and two readings at x = 4
+	series := []Point{{X: 6, Y: 8}, {X: 0, Y: 0}, {X: 4, Y: 1}, {X: 2, Y: 4}, {X: 4, Y: 3}}
+	got, err := Interpolate(series, 1, interpolateLinear)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{X: 0, Y: 0}, {X: 1, Y: 2}, {X: 2, Y: 4}, {X: 3, Y: 3}, {X: 4, Y: 2}, {X: 5, Y: 5}, {X: 6, Y: 8}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// a step beyond the X range
+	if got, _ := Interpolate(series, 10, interpolateLinear); !reflect.DeepEqual(got, []Point{{X: 0, Y: 0}}) {
+		t.Errorf("step 10: got %v", got)
+	}
+	if _, err := Interpolate(series, 1e-9, interpolateLinear); err != ErrTooManyPoints {
+		t.Errorf("tiny step: got %v, want %v", err, ErrTooManyPoints)
+	}
+}
+
+func TestInterpolateMethodsKeepKnots(t *testing.T) {
+	series := []Point{{X: 0, Y: 1}, {X: 2, Y: 5}, {X: 4, Y: -1}, {X: 6, Y: 2}}
+	for name, method := range InterpolationMethods {
+		got, err := Interpolate(series, 2, method)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, pt := range got {
+			if !closeTo(pt.Y, series[i].Y) {
+				t.Errorf("%s: got %v at x = %v, want the point's %v", name, pt.Y, pt.X, series[i].Y)
+			}
+		}
+	}
+	// ties go to the left neighbour
+	if got := interpolateNearest(series, []float64{1, 1.5, 2.9, 3.1}); got[0].Y != 1 || got[1].Y != 5 || got[2].Y != 5 || got[3].Y != -1 {
+		t.Errorf("nearest got %v", got)
+	}
+}
@@ -0,0 +1,30 @@
+package compute
+
+import "math"
+
+// where two fitted lines cross. Point is nil when the lines are parallel,
+// and Coincident is set when they are the same line.
+type Intersection struct {
+	Envelope
+	Line1      RegressionLine `json:"line1"`
+	Line2      RegressionLine `json:"line2"`
+	Point      *Point         `json:"point,omitempty"`
+	Parallel   bool           `json:"parallel"`
+	Coincident bool           `json:"coincident"`
+}
+
+// solves slope1*x + intercept1 = slope2*x + intercept2
+func IntersectLines(line1, line2 RegressionLine) *Intersection {
+	const epsilon = 1e-9
+	intersection := &Intersection{Envelope: NewEnvelope(), Line1: line1, Line2: line2}
+	scale := math.Max(1, math.Max(math.Abs(line1.Slope), math.Abs(line2.Slope)))
+	if math.Abs(line1.Slope-line2.Slope) <= epsilon*scale {
+		intersection.Parallel = true
+		scale = math.Max(1, math.Max(math.Abs(line1.Intercept), math.Abs(line2.Intercept)))
+		intersection.Coincident = math.Abs(line1.Intercept-line2.Intercept) <= epsilon*scale
+		return intersection
+	}
+	x := (line2.Intercept - line1.Intercept) / (line1.Slope - line2.Slope)
+	intersection.Point = &Point{X: x, Y: line1.Slope*x + line1.Intercept}
+	return intersection
+}
@@ -0,0 +1,26 @@
+package compute
+
+import "testing"
+
+func TestIntersectLines(t *testing.T) {
+	tests := []struct {
+		name                 string
+		line1, line2         RegressionLine
+		point                *Point
+		parallel, coincident bool
+	}{
+		{"crossing", RegressionLine{Slope: 2, Intercept: 1}, RegressionLine{Slope: -1, Intercept: 7}, &Point{X: 2, Y: 5}, false, false},
+		{"parallel", RegressionLine{Slope: 2, Intercept: 1}, RegressionLine{Slope: 2, Intercept: 4}, nil, true, false},
+		{"coincident", RegressionLine{Slope: 2, Intercept: 1}, RegressionLine{Slope: 2, Intercept: 1}, nil, true, true},
+	}
+	for _, test := range tests {
+		got := IntersectLines(test.line1, test.line2)
+		if got.Parallel != test.parallel || got.Coincident != test.coincident {
+			t.Errorf("%s: got parallel %v coincident %v, want %v %v", test.name, got.Parallel, got.Coincident, test.parallel, test.coincident)
+		}
+		if (got.Point == nil) != (test.point == nil) ||
+			got.Point != nil && (!closeTo(got.Point.X, test.point.X) || !closeTo(got.Point.Y, test.point.Y)) {
+			t.Errorf("%s: got point %v, want %v", test.name, got.Point, test.point)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package compute
+
+import "math"
+
+// residuals below this get the weight of this, so a point on the line
+// doesn't get an infinite weight
+const ladMinResidual = 1e-9
+
+// least absolute deviations (median) regression by iteratively reweighted
+// least squares: each pass refits with weights 1/|residual| from the
+// previous fit, starting from ordinary least squares. Stops once the fit
+// settles or after maxIterations passes.
+func LADRegression(series []Point, maxIterations int) RegressionLine {
+	line := FitLine(series)
+	slope, intercept := line.Slope, line.Intercept
+	weights := make([]float64, len(series))
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		for i, pt := range series {
+			weights[i] = 1 / math.Max(math.Abs(pt.Y-(slope*pt.X+intercept)), ladMinResidual)
+		}
+		newSlope, newIntercept := weightedLine(series, weights)
+		if math.IsNaN(newSlope) {
+			break
+		}
+		settled := math.Abs(newSlope-slope) <= 1e-12*math.Max(1, math.Abs(slope)) &&
+			math.Abs(newIntercept-intercept) <= 1e-12*math.Max(1, math.Abs(intercept))
+		slope, intercept = newSlope, newIntercept
+		if settled {
+			break
+		}
+	}
+
+	sumAbs := 0.0
+	for _, pt := range series {
+		sumAbs += math.Abs(pt.Y - (slope*pt.X + intercept))
+	}
+	stdError, correlation := residualStats(series, slope, intercept)
+	lad := RegressionLine{Slope: slope,
+		Intercept:       intercept,
+		StdError:        stdError,
+		Correlation:     correlation,
+		Equation:        lineEquation("linear", []float64{intercept, slope}, correlation),
+		Model:           "lad",
+		SumAbsResiduals: sumAbs}
+	lad.setCrossings(series)
+	return lad
+}
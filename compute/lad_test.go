@@ -0,0 +1,28 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLADIgnoresOutlier(t *testing.T) {
+	// y = 2x + 1 with one point far above it
+	var series []Point
+	for x := 0.0; x < 10; x++ {
+		series = append(series, Point{X: x, Y: 2*x + 1})
+	}
+	series[8].Y = 100
+
+	ols := FitLine(series)
+	lad := LADRegression(series, 50)
+	if math.Abs(ols.Slope-2) < 1 {
+		t.Fatalf("OLS slope %v isn't pulled off by the outlier", ols.Slope)
+	}
+	if math.Abs(lad.Slope-2) > 1e-3 || math.Abs(lad.Intercept-1) > 1e-3 {
+		t.Errorf("LAD got y = %vx + %v, want y = 2x + 1", lad.Slope, lad.Intercept)
+	}
+	// the outlier's residual is nearly all there is
+	if math.Abs(lad.SumAbsResiduals-83) > 0.1 {
+		t.Errorf("sum of |residuals| %v, want about 83", lad.SumAbsResiduals)
+	}
+}
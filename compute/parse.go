@@ -0,0 +1,310 @@
+package compute
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// default cap on the lines read from an input
+const MAXLINES = 1000000
+
+var ErrUnknownCSVMode = errors.New("unknown csvMode")
+var ErrUnknownColumn = errors.New("unknown column")
+var ErrDuplicateColumn = errors.New("duplicate column name in header")
+var ErrTooManyLines = errors.New("more lines than maxLines")
+var errTooFewFields = errors.New("expected at least 2 fields")
+var errNotFinite = errors.New("number out of range")
+
+// ends reading once ParseOptions.Limit points are in
+var errLimitReached = errors.New("point limit reached")
+
+// how ParseSeriesWith reads its input
+type ParseOptions struct {
+	// "" splits each line on commas; "rfc4180" reads quoted fields with
+	// embedded commas and quotes, and allows grouping commas in numbers
+	CSVMode string
+	// the columns holding x and y, as 0-based indices or as names from a
+	// header row; "" for the first and second column
+	XColumn, YColumn string
+	// fail on the first line that doesn't hold two numbers, rather than
+	// skipping it
+	Strict bool
+	// stop reading after this many points, 0 to read them all
+	Limit int
+	// input with more lines than this is rejected; 0 for MAXLINES
+	MaxLines int
+	// set Point.Line on each point read
+	LineNumbers bool
+}
+
+// parses "x,y" lines into a data series. Blank lines and lines starting
+// with # are ignored, and so is a leading UTF-8 byte order mark.
+func ParseSeries(src string) (series []Point, err error) {
+	return ParseSeriesWith(src, ParseOptions{})
+}
+
+// a data series along with what else the input told about it
+type Parsed struct {
+	Series []Point
+	// from the first row when it is a header, i.e. has no numbers in it
+	ColumnNames []string
+	// lines skipped for not holding two numbers, and why the first one was
+	SkippedLines int
+	FirstError   string
+	// the first MaxLineErrors of the skipped lines
+	Errors []LineError
+}
+
+// how many skipped lines Parsed.Errors keeps
+const MaxLineErrors = 10
+
+// a skipped line, as it was read and why
+type LineError struct {
+	Line   int    `json:"line"`
+	Raw    string `json:"raw"`
+	Reason string `json:"reason"`
+}
+
+// parses a data series, skipping lines that don't hold two numbers
+func ParseSeriesWith(src string, options ParseOptions) (series []Point, err error) {
+	parsed, err := ParseColumns(src, options)
+	if err != nil {
+		return nil, err
+	}
+	return parsed.Series, nil
+}
+
+// parses a data series like ParseSeriesWith, also returning the column
+// names and the lines that were skipped. In strict mode a bad line is a
+// *ParseError instead.
+func ParseColumns(src string, options ParseOptions) (parsed *Parsed, err error) {
+	return ParseColumnsContext(context.Background(), src, options)
+}
+
+// the UTF-8 byte order mark spreadsheet programs put at the start of
+// exported files
+const utf8BOM = "\ufeff"
+
+// how many lines ParseColumnsContext reads between checks of the context
+const cancelCheckLines = 4096
+
+// ParseColumns, giving up with the context's error once it is done
+func ParseColumnsContext(ctx context.Context, src string, options ParseOptions) (parsed *Parsed, err error) {
+	maxLines := options.MaxLines
+	if maxLines <= 0 {
+		maxLines = MAXLINES
+	}
+	// only at the very start; anywhere else it is part of a field
+	src = strings.TrimPrefix(src, utf8BOM)
+	var records func(each func(line int, record []string) error) error
+	switch options.CSVMode {
+	case "":
+		records = func(each func(line int, record []string) error) error { return splitLines(src, maxLines, each) }
+	case "rfc4180":
+		records = func(each func(line int, record []string) error) error { return readRFC4180(src, maxLines, each) }
+	default:
+		return nil, &ParseError{Err: ErrUnknownCSVMode}
+	}
+
+	parsed = &Parsed{Series: make([]Point, 0)}
+	read := 0
+	xcol, ycol := -1, -1
+	// the Y error column is only read along with the default columns
+	withYErr := options.XColumn == "" && options.YColumn == ""
+	err = records(func(line int, record []string) error {
+		read++
+		if read%cancelCheckLines == 0 && ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			return nil
+		}
+		// comments, like the summary of a CSV export
+		if strings.HasPrefix(strings.TrimSpace(record[0]), "#") {
+			return nil
+		}
+		if xcol < 0 {
+			if IsHeader(record) {
+				parsed.ColumnNames = make([]string, len(record))
+				for i, name := range record {
+					parsed.ColumnNames[i] = strings.TrimSpace(name)
+				}
+			}
+			var err error
+			if xcol, ycol, err = pickColumns(options, parsed.ColumnNames); err != nil {
+				return &ParseError{Line: line, Err: err}
+			}
+			if parsed.ColumnNames != nil {
+				return nil
+			}
+		}
+		pt, err := parseFields(record, xcol, ycol, withYErr)
+		if err != nil && options.Strict {
+			return &ParseError{Line: line, Err: err}
+		} else if err != nil {
+			if parsed.SkippedLines == 0 {
+				parsed.FirstError = (&ParseError{Line: line, Err: err}).Error()
+			}
+			if len(parsed.Errors) < MaxLineErrors {
+				parsed.Errors = append(parsed.Errors, LineError{line, strings.Join(record, ","), err.Error()})
+			}
+			parsed.SkippedLines++
+			return nil
+		}
+		if options.LineNumbers {
+			pt.Line = line
+		}
+		parsed.Series = append(parsed.Series, pt)
+		if options.Limit > 0 && len(parsed.Series) >= options.Limit {
+			return errLimitReached
+		}
+		return nil
+	})
+	if err == errLimitReached {
+		err = nil
+	}
+	if err == nil && xcol < 0 {
+		// nothing to read, but the column choice can still be wrong
+		if _, _, err = pickColumns(options, nil); err != nil {
+			err = &ParseError{Err: err}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// resolves the X and Y columns against the header, if there is one
+func pickColumns(options ParseOptions, columnNames []string) (xcol, ycol int, err error) {
+	seen := make(map[string]bool)
+	for _, name := range columnNames {
+		if seen[name] {
+			return 0, 0, ErrDuplicateColumn
+		}
+		seen[name] = true
+	}
+	if xcol, err = pickColumn(options.XColumn, 0, columnNames); err != nil {
+		return 0, 0, err
+	}
+	if ycol, err = pickColumn(options.YColumn, 1, columnNames); err != nil {
+		return 0, 0, err
+	}
+	return xcol, ycol, nil
+}
+
+func pickColumn(column string, def int, columnNames []string) (int, error) {
+	if column == "" {
+		return def, nil
+	}
+	if i, err := strconv.Atoi(column); err == nil && i >= 0 {
+		return i, nil
+	}
+	for i, name := range columnNames {
+		if name == column {
+			return i, nil
+		}
+	}
+	return 0, ErrUnknownColumn
+}
+
+// a row of names rather than numbers
+func IsHeader(record []string) bool {
+	if len(record) < 2 {
+		return false
+	}
+	for _, field := range record {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// calls each with the line number and comma separated fields of every line,
+// failing on line maxLines+1
+func splitLines(src string, maxLines int, each func(line int, record []string) error) error {
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	for i := 1; scanner.Scan(); i++ {
+		if i > maxLines {
+			return &ParseError{Line: i, Err: ErrTooManyLines}
+		}
+		if err := each(i, strings.Split(scanner.Text(), ",")); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// reads a point from the X and Y fields of a record
+func parseFields(fields []string, xcol, ycol int, withYErr bool) (pt Point, err error) {
+	if len(fields) < 2 || xcol >= len(fields) || ycol >= len(fields) {
+		return pt, errTooFewFields
+	}
+	if pt.X, err = parseNumber(fields[xcol]); err != nil {
+		return pt, err
+	}
+	if pt.Y, err = parseNumber(fields[ycol]); err != nil {
+		return pt, err
+	}
+	// an optional fourth column is the Y error; anything but a positive
+	// number there is taken as no error
+	if withYErr && len(fields) >= 4 {
+		if yerr, err := strconv.ParseFloat(strings.TrimSpace(fields[3]), 64); err == nil && yerr > 0 {
+			pt.YErr = yerr
+		}
+	}
+	return pt, nil
+}
+
+// parses a finite number. Literals beyond the float64 range, like 1e400,
+// as well as Inf and NaN are rejected: they can't be fitted or sent as JSON.
+func parseNumber(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return v, err
+	}
+	// underflow to 0 is harmless, overflow to ±Inf is not
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return v, fmt.Errorf("%s: %w", strconv.Quote(field), errNotFinite)
+	}
+	return v, nil
+}
+
+// calls each with the line a record starts on and its fields, for every
+// RFC 4180 record, failing on record maxLines+1. Blank lines are skipped and
+// quoted fields may span lines, so the line isn't the record count.
+func readRFC4180(src string, maxLines int, each func(line int, record []string) error) error {
+	csvReader := csv.NewReader(strings.NewReader(src))
+	csvReader.FieldsPerRecord = -1
+	csvReader.TrimLeadingSpace = true
+	for i := 1; ; i++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		} else if err == nil && i > maxLines {
+			return &ParseError{Line: i, Err: ErrTooManyLines}
+		} else if csvErr, ok := err.(*csv.ParseError); ok {
+			return &ParseError{Line: csvErr.Line, Err: csvErr.Err}
+		} else if err != nil {
+			return err
+		}
+		// a comma inside a field can only be digit grouping, "1,000"
+		for j := range record {
+			record[j] = strings.Replace(record[j], ",", "", -1)
+		}
+		line, _ := csvReader.FieldPos(0)
+		if err = each(line, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
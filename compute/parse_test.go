@@ -0,0 +1,169 @@
+package compute
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRFC4180(t *testing.T) {
+	src := "\"1,000\",\"2.5\"\n" +
+		"\"2,000\", \"3,500.25\",\"a \"\"quoted\"\", comma\"\n" +
+		"3000,4\n"
+	series, err := ParseSeriesWith(src, ParseOptions{CSVMode: "rfc4180"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{X: 1000, Y: 2.5}, {X: 2000, Y: 3500.25}, {X: 3000, Y: 4}}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("got %v, want %v", series, want)
+	}
+
+	// split on every comma, the quoted numbers don't parse
+	if series, _ := ParseSeries(src); len(series) != 1 {
+		t.Errorf("without csvMode got %v, want only the unquoted line", series)
+	}
+	if _, err := ParseSeriesWith("\"1,2\n", ParseOptions{CSVMode: "rfc4180"}); err == nil {
+		t.Error("an unterminated quote parsed")
+	}
+}
+
+func TestParseYErrColumn(t *testing.T) {
+	series, err := ParseSeries("1,2,note,0.5\n2,4\n3,6,note,-1\n4,8,note,x\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{X: 1, Y: 2, YErr: 0.5}, {X: 2, Y: 4}, {X: 3, Y: 6}, {X: 4, Y: 8}}
+	if !reflect.DeepEqual(series, want) {
+		t.Errorf("got %v, want %v", series, want)
+	}
+
+	// with named columns the fourth field is just another column
+	series, err = ParseSeriesWith("a,b,c,d\n1,2,3,0.5\n", ParseOptions{XColumn: "a", YColumn: "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 1 || series[0].YErr != 0 {
+		t.Errorf("got %v, want no Y error", series)
+	}
+}
+
+func TestParseNamedColumns(t *testing.T) {
+	src := "time,temperature,pressure\n1,20,1000\n2,21,990\n"
+	tests := []struct {
+		xcol, ycol string
+		want       []Point
+	}{
+		{"time", "pressure", []Point{{X: 1, Y: 1000}, {X: 2, Y: 990}}},
+		{"0", "2", []Point{{X: 1, Y: 1000}, {X: 2, Y: 990}}},
+		{"temperature", "0", []Point{{X: 20, Y: 1}, {X: 21, Y: 2}}},
+		{"", "", []Point{{X: 1, Y: 20}, {X: 2, Y: 21}}},
+	}
+	for _, test := range tests {
+		parsed, err := ParseColumns(src, ParseOptions{XColumn: test.xcol, YColumn: test.ycol})
+		if err != nil {
+			t.Errorf("xcol %q, ycol %q: %v", test.xcol, test.ycol, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed.Series, test.want) {
+			t.Errorf("xcol %q, ycol %q: got %v, want %v", test.xcol, test.ycol, parsed.Series, test.want)
+		}
+		if !reflect.DeepEqual(parsed.ColumnNames, []string{"time", "temperature", "pressure"}) {
+			t.Errorf("column names %q", parsed.ColumnNames)
+		}
+	}
+
+	if _, err := ParseColumns("a,b,a\n1,2,3\n", ParseOptions{}); !errors.Is(err, ErrDuplicateColumn) {
+		t.Errorf("duplicate names: got %v, want %v", err, ErrDuplicateColumn)
+	}
+	if _, err := ParseColumns(src, ParseOptions{YColumn: "humidity"}); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("unknown name: got %v, want %v", err, ErrUnknownColumn)
+	}
+}
+
+func TestParseRejectsOverflow(t *testing.T) {
+	parsed, err := ParseColumns("1e400,2\n1,-1e400\n1,inf\n1,NaN\n1e-400,2\n", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// underflow to 0 is kept
+	if want := []Point{{X: 0, Y: 2}}; !reflect.DeepEqual(parsed.Series, want) {
+		t.Errorf("got %v, want %v", parsed.Series, want)
+	}
+	if parsed.SkippedLines != 4 || parsed.FirstError != `line 1: "1e400": number out of range` {
+		t.Errorf("skipped %d lines, first error %q", parsed.SkippedLines, parsed.FirstError)
+	}
+
+	_, err = ParseColumns("1,2\n1e400,2\n", ParseOptions{Strict: true})
+	var parseError *ParseError
+	if !errors.As(err, &parseError) || parseError.Line != 2 || !errors.Is(err, errNotFinite) {
+		t.Errorf("strict: got %v, want line 2 out of range", err)
+	}
+}
+
+func TestParseColumnsContext(t *testing.T) {
+	var src strings.Builder
+	for i := 0; i < 3*cancelCheckLines; i++ {
+		fmt.Fprintf(&src, "%d,%d\n", i, 2*i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ParseColumnsContext(ctx, src.String(), ParseOptions{}); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if parsed, err := ParseColumnsContext(context.Background(), src.String(), ParseOptions{}); err != nil || len(parsed.Series) != 3*cancelCheckLines {
+		t.Errorf("not cancelled: got %v", err)
+	}
+}
+
+func TestParseLineNumbers(t *testing.T) {
+	src := "x,y\n1,2\n\n# a comment\nbad,line\n2,4\n3,6\n"
+	parsed, err := ParseColumns(src, ParseOptions{LineNumbers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Point{{X: 1, Y: 2, Line: 2}, {X: 2, Y: 4, Line: 6}, {X: 3, Y: 6, Line: 7}}
+	if !reflect.DeepEqual(parsed.Series, want) {
+		t.Errorf("got %v, want %v", parsed.Series, want)
+	}
+	// a quoted field over two lines leaves the next record a line further on
+	src = "\"1\",\"2\"\n\"2\",\"4\n\"\n3,6\n"
+	parsed, err = ParseColumns(src, ParseOptions{LineNumbers: true, CSVMode: "rfc4180"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := []int{parsed.Series[0].Line, parsed.Series[1].Line, parsed.Series[2].Line}; !reflect.DeepEqual(lines, []int{1, 2, 4}) {
+		t.Errorf("rfc4180: got lines %v, want [1 2 4]", lines)
+	}
+	if parsed, _ := ParseColumns("1,2\n2,4\n", ParseOptions{}); parsed.Series[1].Line != 0 {
+		t.Errorf("line numbers without LineNumbers: %v", parsed.Series)
+	}
+}
+
+func TestParseStripsLeadingBOM(t *testing.T) {
+	for _, mode := range []string{"", "rfc4180"} {
+		parsed, err := ParseColumns(utf8BOM+"1,2\n2,4\n", ParseOptions{CSVMode: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []Point{{X: 1, Y: 2}, {X: 2, Y: 4}}; !reflect.DeepEqual(parsed.Series, want) {
+			t.Errorf("mode %q: got %v, want %v", mode, parsed.Series, want)
+		}
+		// a header after a BOM is still a header
+		parsed, err = ParseColumns(utf8BOM+"time,temp\n1,2\n", ParseOptions{CSVMode: mode})
+		if err != nil || !reflect.DeepEqual(parsed.ColumnNames, []string{"time", "temp"}) {
+			t.Errorf("mode %q: got columns %q, %v", mode, parsed.ColumnNames, err)
+		}
+	}
+	// only a leading one is stripped
+	parsed, err := ParseColumns("1,2\n"+utf8BOM+"2,4\n3,6\n", ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Series) != 2 || parsed.SkippedLines != 1 || parsed.Errors[0].Line != 2 {
+		t.Errorf("mid-file BOM: got %v with %d skipped", parsed.Series, parsed.SkippedLines)
+	}
+}
@@ -0,0 +1,29 @@
+package compute
+
+import "errors"
+
+var ErrSplitOrder = errors.New("split points must be in ascending order")
+
+// splits the series at the given X values into len(splits)+1 parts. Part i
+// holds the points with splits[i-1] <= X < splits[i], the first part those
+// below splits[0] and the last those from the last split on. Points keep
+// their order within each part.
+func PartitionByX(series []Point, splits []float64) ([][]Point, error) {
+	for i := 1; i < len(splits); i++ {
+		if splits[i] <= splits[i-1] {
+			return nil, ErrSplitOrder
+		}
+	}
+	parts := make([][]Point, len(splits)+1)
+	for i := range parts {
+		parts[i] = make([]Point, 0)
+	}
+	for _, pt := range series {
+		i := 0
+		for i < len(splits) && pt.X >= splits[i] {
+			i++
+		}
+		parts[i] = append(parts[i], pt)
+	}
+	return parts, nil
+}
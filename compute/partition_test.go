@@ -0,0 +1,31 @@
+package compute
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartitionByX(t *testing.T) {
+	series := []Point{{X: 250, Y: 1}, {X: 5, Y: 2}, {X: 100, Y: 3}, {X: 150, Y: 4}, {X: 99.9, Y: 5}, {X: 200, Y: 6}}
+	parts, err := PartitionByX(series, []float64{100, 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a point on a split goes to the part above it; order is kept
+	want := [][]Point{
+		{{X: 5, Y: 2}, {X: 99.9, Y: 5}},
+		{{X: 100, Y: 3}, {X: 150, Y: 4}},
+		{{X: 250, Y: 1}, {X: 200, Y: 6}},
+	}
+	if !reflect.DeepEqual(parts, want) {
+		t.Errorf("got %v, want %v", parts, want)
+	}
+	if parts, _ := PartitionByX(series, []float64{1000}); len(parts) != 2 || len(parts[0]) != 6 || len(parts[1]) != 0 {
+		t.Errorf("split past the end: got %v", parts)
+	}
+	for _, splits := range [][]float64{{200, 100}, {100, 100}} {
+		if _, err := PartitionByX(series, splits); err != ErrSplitOrder {
+			t.Errorf("splits %v: got %v, want %v", splits, err, ErrSplitOrder)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrSingular = errors.New("singular matrix")
+
+// least squares polynomial fit of the given degree using the normal equations.
+// Returns the coefficients in ascending powers of x.
+func PolynomialRegression(series []Point, degree int) (coefficients []float64, err error) {
+	if len(series) <= degree {
+		return nil, &RegressionError{Model: "polynomial", Err: errors.New("not enough points for polynomial degree")}
+	}
+	matrix, rhs := NormalEquations(series, degree)
+	if coefficients, err = SolveLinearSystem(matrix, rhs); err != nil {
+		return nil, &RegressionError{Model: "polynomial", Err: err}
+	}
+	return coefficients, nil
+}
+
+// the normal equations matrix·coefficients = rhs of a polynomial fit
+func NormalEquations(series []Point, degree int) (matrix [][]float64, rhs []float64) {
+	size := degree + 1
+	// sums of x^0 .. x^(2*degree) and of y*x^0 .. y*x^degree
+	powerSums := make([]float64, 2*degree+1)
+	rhs = make([]float64, size)
+	for _, pt := range series {
+		xp := 1.0
+		for i := range powerSums {
+			powerSums[i] += xp
+			if i < size {
+				rhs[i] += pt.Y * xp
+			}
+			xp *= pt.X
+		}
+	}
+	matrix = make([][]float64, size)
+	for row := range matrix {
+		matrix[row] = make([]float64, size)
+		for col := range matrix[row] {
+			matrix[row][col] = powerSums[row+col]
+		}
+	}
+	return matrix, rhs
+}
+
+// solves a*x = b by Gaussian elimination with partial pivoting.
+// a and b are modified in place.
+func SolveLinearSystem(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, ErrSingular
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for row := col + 1; row < n; row++ {
+			factor := a[row][col] / a[col][col]
+			for k := col; k < n; k++ {
+				a[row][k] -= factor * a[col][k]
+			}
+			b[row] -= factor * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := b[row]
+		for k := row + 1; k < n; k++ {
+			sum -= a[row][k] * x[k]
+		}
+		x[row] = sum / a[row][row]
+	}
+	return x, nil
+}
+
+// evaluates the polynomial with coefficients in ascending powers at x
+func EvalPolynomial(coefficients []float64, x float64) float64 {
+	y := 0.0
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = y*x + coefficients[i]
+	}
+	return y
+}
+
+// residual (sr) and total (st) sums of squares of a polynomial fit
+func SumsOfSquares(series []Point, coefficients []float64) (sr float64, st float64) {
+	ymean := 0.0
+	for _, pt := range series {
+		ymean += pt.Y
+	}
+	ymean /= float64(len(series))
+	for _, pt := range series {
+		r := pt.Y - EvalPolynomial(coefficients, pt.X)
+		sr += r * r
+		st += (pt.Y - ymean) * (pt.Y - ymean)
+	}
+	return sr, st
+}
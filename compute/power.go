@@ -0,0 +1,50 @@
+package compute
+
+import (
+	"errors"
+)
+
+// largest sample size RequiredSampleSize looks at
+const MaxPowerN = 1000000
+
+var ErrPowerUnreachable = errors.New("power not reached within the sample size limit")
+
+// power of the F test for a non-zero slope in a simple linear regression
+// of n points, at significance alpha, when the effect size is Cohen's f²
+// (R²/(1-R²)). The noncentrality is f²·n, as in G*Power.
+func RegressionPower(n int, f2, alpha float64) float64 {
+	d2 := float64(n - 2)
+	critical := FQuantile(1-alpha, 1, d2)
+	return 1 - NoncentralFCDF(critical, 1, d2, f2*float64(n))
+}
+
+// the smallest number of points for which the slope test reaches power
+type SampleSize struct {
+	RequiredN     int     `json:"requiredN"`
+	AchievedPower float64 `json:"achievedPower"`
+	Alpha         float64 `json:"alpha"`
+}
+
+// finds the smallest n with RegressionPower(n, f2, alpha) >= power.
+// Power grows with n, so the search doubles n and then bisects.
+func RequiredSampleSize(f2, alpha, power float64) (SampleSize, error) {
+	lo, hi := 2, 3
+	for RegressionPower(hi, f2, alpha) < power {
+		if hi >= MaxPowerN {
+			return SampleSize{}, ErrPowerUnreachable
+		}
+		lo, hi = hi, hi*2
+		if hi > MaxPowerN {
+			hi = MaxPowerN
+		}
+	}
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if RegressionPower(mid, f2, alpha) < power {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return SampleSize{RequiredN: hi, AchievedPower: RegressionPower(hi, f2, alpha), Alpha: alpha}, nil
+}
@@ -0,0 +1,33 @@
+package compute
+
+import "testing"
+
+func TestRequiredSampleSize(t *testing.T) {
+	// G*Power, linear multiple regression with one predictor, at Cohen's
+	// small, medium and large effect sizes
+	tests := []struct {
+		f2, alpha, power float64
+		want             int
+	}{
+		{0.02, 0.05, 0.8, 395},
+		{0.15, 0.05, 0.8, 55},
+		{0.35, 0.05, 0.8, 25},
+		{0.15, 0.05, 0.95, 89},
+	}
+	for _, test := range tests {
+		size, err := RequiredSampleSize(test.f2, test.alpha, test.power)
+		if err != nil {
+			t.Errorf("f² %v: %v", test.f2, err)
+			continue
+		}
+		if size.RequiredN != test.want || size.AchievedPower < test.power || size.Alpha != test.alpha {
+			t.Errorf("f² %v, α %v, power %v: got %+v, want n = %d", test.f2, test.alpha, test.power, size, test.want)
+		}
+		if below := RegressionPower(size.RequiredN-1, test.f2, test.alpha); below >= test.power {
+			t.Errorf("f² %v: n = %d already has power %v", test.f2, size.RequiredN-1, below)
+		}
+	}
+	if _, err := RequiredSampleSize(1e-9, 0.05, 0.99); err != ErrPowerUnreachable {
+		t.Errorf("tiny effect: got %v, want %v", err, ErrPowerUnreachable)
+	}
+}
@@ -0,0 +1,61 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrNotPositive = errors.New("a log-log fit needs positive x and y")
+
+// fits y = a·x^b by least squares on ln y = ln a + b·ln x. Slope and
+// Intercept are those of the log-log line; Coefficients are a, b, and the
+// standard error and R² are of the power law in the original units.
+func LogLogFit(series []Point) (RegressionLine, error) {
+	for _, pt := range series {
+		if pt.X <= 0 || pt.Y <= 0 {
+			return RegressionLine{}, &RegressionError{Model: "powerlaw", Err: ErrNotPositive}
+		}
+	}
+	return transformedFit(series, "powerlaw", math.Log, math.Log,
+		func(slope, intercept float64) []float64 { return []float64{math.Exp(intercept), slope} },
+		func(c []float64, x float64) float64 { return c[0] * math.Pow(x, c[1]) }), nil
+}
+
+// fits a line to the points with their x and y transformed, and reports it
+// as the model with the given coefficients. The fit is judged, like
+// LogLogFit, by how well predict matches the points in the original units.
+func transformedFit(series []Point, model string, tx, ty func(float64) float64,
+	coefficients func(slope, intercept float64) []float64, predict func(c []float64, x float64) float64) RegressionLine {
+	transformed := make([]Point, len(series))
+	for i, pt := range series {
+		transformed[i] = Point{X: tx(pt.X), Y: ty(pt.Y)}
+	}
+	slope, intercept, _, _ := LinearRegression(transformed)
+	c := coefficients(slope, intercept)
+
+	n := float64(len(series))
+	ymean, xmean := 0.0, 0.0
+	for _, pt := range series {
+		xmean += pt.X / n
+		ymean += pt.Y / n
+	}
+	sr, st := 0.0, 0.0
+	for _, pt := range series {
+		r := pt.Y - predict(c, pt.X)
+		sr += r * r
+		st += (pt.Y - ymean) * (pt.Y - ymean)
+	}
+	// the model isn't the least squares fit in the original units, so it
+	// can do worse than the mean
+	correlation := math.Sqrt(math.Max(st-sr, 0) / st)
+	return RegressionLine{Slope: slope,
+		Intercept:   intercept,
+		StdError:    math.Sqrt(sr / (n - 2)),
+		Correlation: correlation,
+		// none of these models has a straight line's crossing of y=0
+		XInterceptUndefined: true,
+		FittedAtMeanX:       predict(c, xmean),
+		Equation:            lineEquation(model, c, correlation),
+		Model:               model,
+		Coefficients:        c}
+}
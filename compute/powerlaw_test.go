@@ -0,0 +1,34 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestLogLogFitRecoversPowerLaw(t *testing.T) {
+	// y = 3·x^1.7
+	var series []Point
+	for x := 0.5; x < 50; x *= 1.5 {
+		series = append(series, Point{X: x, Y: 3 * math.Pow(x, 1.7)})
+	}
+	line, err := LogLogFit(series)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(line.Coefficients) != 2 || math.Abs(line.Coefficients[0]-3) > 1e-9 || math.Abs(line.Coefficients[1]-1.7) > 1e-9 {
+		t.Errorf("coefficients %v, want [3 1.7]", line.Coefficients)
+	}
+	if line.Model != "powerlaw" || math.Abs(line.Slope-1.7) > 1e-9 || math.Abs(line.Intercept-math.Log(3)) > 1e-9 {
+		t.Errorf("got %s with log-log line %vx + %v", line.Model, line.Slope, line.Intercept)
+	}
+	if math.Abs(line.Correlation-1) > 1e-9 || line.StdError > 1e-9 {
+		t.Errorf("R %v and standard error %v, want an exact fit", line.Correlation, line.StdError)
+	}
+
+	series = append(series, Point{X: 0, Y: 1})
+	var regressionError *RegressionError
+	if _, err := LogLogFit(series); !errors.As(err, &regressionError) || !errors.Is(err, ErrNotPositive) {
+		t.Errorf("x = 0: got %v, want %v", err, ErrNotPositive)
+	}
+}
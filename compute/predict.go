@@ -0,0 +1,44 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+// the least squares line at a new x, with the range a new observation
+// there falls in at the requested level
+type Prediction struct {
+	X                  float64    `json:"x"`
+	Y                  float64    `json:"y"`
+	PredictionInterval [2]float64 `json:"predictionInterval"`
+}
+
+var errPredictionPoints = errors.New("at least 3 points with distinct x are needed for a prediction interval")
+
+// predicts y at each of xs from the least squares line through series.
+// Unlike a confidence interval for the line itself, the prediction interval
+// includes the scatter of the points around the line, so it stays wide
+// however many points there are:
+//
+//	ŷ ± t(level, n-2) · s · √(1 + 1/n + (x - x̄)² / Σ(xᵢ - x̄)²)
+func Predict(series []Point, xs []float64, level float64) ([]Prediction, error) {
+	n := float64(len(series))
+	sums := SeriesSums(series)
+	sxx := 0.0
+	for _, pt := range series {
+		sxx += (pt.X - sums.XMean) * (pt.X - sums.XMean)
+	}
+	if len(series) < 3 || sxx == 0 {
+		return nil, &RegressionError{Model: "ols", Err: errPredictionPoints}
+	}
+	slope, intercept, stdError, _ := LinearRegression(series)
+	t := TQuantile(1-(1-level)/2, n-2)
+
+	predictions := make([]Prediction, len(xs))
+	for i, x := range xs {
+		y := slope*x + intercept
+		half := t * stdError * math.Sqrt(1+1/n+(x-sums.XMean)*(x-sums.XMean)/sxx)
+		predictions[i] = Prediction{X: x, Y: y, PredictionInterval: [2]float64{y - half, y + half}}
+	}
+	return predictions, nil
+}
@@ -0,0 +1,48 @@
+package compute
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestPredictIntervalWidensAwayFromMean(t *testing.T) {
+	series := []Point{{X: 0, Y: 1.2}, {X: 1, Y: 2.7}, {X: 2, Y: 5.3}, {X: 3, Y: 6.8}, {X: 4, Y: 9.1},
+		{X: 5, Y: 10.9}, {X: 6, Y: 13.2}, {X: 7, Y: 14.8}, {X: 8, Y: 17.1}, {X: 9, Y: 19.2}}
+	xs := []float64{4.5, 0, 9, -5, 30}
+	predictions, err := Predict(series, xs, 0.95)
+	if err != nil {
+		t.Fatal(err)
+	}
+	width := func(p Prediction) float64 { return p.PredictionInterval[1] - p.PredictionInterval[0] }
+
+	slope, intercept, stdError, _ := LinearRegression(series)
+	n := float64(len(series))
+	if want := 2 * TQuantile(0.975, n-2) * stdError * math.Sqrt(1+1/n); !closeTo(width(predictions[0]), want) {
+		t.Errorf("width at the mean %v, want %v", width(predictions[0]), want)
+	}
+	// 0 and 9 are as far from the mean
+	if !closeTo(width(predictions[1]), width(predictions[2])) {
+		t.Errorf("widths %v and %v at 0 and 9, want the same", width(predictions[1]), width(predictions[2]))
+	}
+	for i := 1; i < len(predictions); i++ {
+		if i != 2 && width(predictions[i]) <= width(predictions[i-1]) {
+			t.Errorf("width %v at x = %v, want wider than %v at x = %v",
+				width(predictions[i]), xs[i], width(predictions[i-1]), xs[i-1])
+		}
+	}
+	for _, p := range predictions {
+		if !closeTo(p.Y, slope*p.X+intercept) || !closeTo((p.PredictionInterval[0]+p.PredictionInterval[1])/2, p.Y) {
+			t.Errorf("prediction %v not centred on the line", p)
+		}
+	}
+
+	narrow, _ := Predict(series, xs[:1], 0.5)
+	if width(narrow[0]) >= width(predictions[0]) {
+		t.Errorf("50%% interval %v, want narrower than the 95%% one", narrow[0].PredictionInterval)
+	}
+	var regressionError *RegressionError
+	if _, err := Predict(series[:2], xs, 0.95); !errors.As(err, &regressionError) {
+		t.Errorf("2 points: got %v, want a RegressionError", err)
+	}
+}
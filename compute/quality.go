@@ -0,0 +1,65 @@
+package compute
+
+import "math"
+
+// weights of the quality score terms and the grade boundaries
+type QualityWeights struct {
+	Correlation float64 // |r|
+	StdError    float64 // 1 - stdError/sd(Y)
+	Count       float64 // min(1, n/30)
+	Outliers    float64 // 1 - fraction of points beyond 2 standard errors
+	// lowest scores graded "fair", "good" and "excellent"
+	Thresholds [3]float64
+}
+
+var DefaultQualityWeights = QualityWeights{Correlation: 0.4, StdError: 0.3, Count: 0.2, Outliers: 0.1,
+	Thresholds: [3]float64{0.4, 0.6, 0.8}}
+
+var qualityGrades = [4]string{"poor", "fair", "good", "excellent"}
+
+// summarises how much the fitted line can be trusted as a 0..1 score and a grade
+func Quality(series []Point, line RegressionLine, weights QualityWeights) (score float64, grade string) {
+	n := len(series)
+	if n == 0 {
+		return 0, qualityGrades[0]
+	}
+	ymean := 0.0
+	for _, pt := range series {
+		ymean += pt.Y / float64(n)
+	}
+	st := 0.0
+	outliers := 0
+	for _, pt := range series {
+		st += (pt.Y - ymean) * (pt.Y - ymean)
+		if math.Abs(pt.Y-(line.Slope*pt.X+line.Intercept)) > 2*line.StdError {
+			outliers++
+		}
+	}
+
+	score = weights.Correlation*finiteOr(math.Abs(line.Correlation), 0) +
+		weights.Count*math.Min(1, float64(n)/30) +
+		weights.Outliers*(1-float64(outliers)/float64(n))
+	// against the spread of Y rather than its range: the residuals of a
+	// line through noise are about as wide as the noise, but a fraction of
+	// its range, which scored noise "fair" or better
+	if n > 1 && st > 0 {
+		sd := math.Sqrt(st / float64(n-1))
+		score += weights.StdError * math.Max(0, finiteOr(1-line.StdError/sd, 0))
+	}
+	score = math.Max(0, math.Min(1, score))
+
+	grade = qualityGrades[0]
+	for i, threshold := range weights.Thresholds {
+		if score >= threshold {
+			grade = qualityGrades[i+1]
+		}
+	}
+	return score, grade
+}
+
+func finiteOr(v float64, def float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,30 @@
+package compute
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestQualityGrade(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var perfect, noise []Point
+	for i := 0; i < 100; i++ {
+		x := float64(i)
+		perfect = append(perfect, Point{X: x, Y: 3*x - 2})
+		noise = append(noise, Point{X: x, Y: rng.NormFloat64()})
+	}
+	tests := []struct {
+		name   string
+		series []Point
+		grade  string
+	}{
+		{"perfect line", perfect, "excellent"},
+		{"pure noise", noise, "poor"},
+	}
+	for _, test := range tests {
+		score, grade := Quality(test.series, FitLine(test.series), DefaultQualityWeights)
+		if grade != test.grade || score < 0 || score > 1 {
+			t.Errorf("%s: got %v %q, want grade %q", test.name, score, grade, test.grade)
+		}
+	}
+}
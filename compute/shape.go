@@ -0,0 +1,84 @@
+package compute
+
+import "math"
+
+func identity(v float64) float64 { return v }
+
+// fits y = a + b·ln x, for x > 0
+func logarithmicFit(series []Point) RegressionLine {
+	return transformedFit(series, "logarithmic", math.Log, identity,
+		func(slope, intercept float64) []float64 { return []float64{intercept, slope} },
+		func(c []float64, x float64) float64 { return c[0] + c[1]*math.Log(x) })
+}
+
+// fits y = a·e^(bx), for y > 0
+func exponentialFit(series []Point) RegressionLine {
+	return transformedFit(series, "exponential", identity, math.Log,
+		func(slope, intercept float64) []float64 { return []float64{math.Exp(intercept), slope} },
+		func(c []float64, x float64) float64 { return c[0] * math.Exp(c[1]*x) })
+}
+
+// a log transform of an axis is left out when its smallest value is below
+// this fraction of the axis' range: ln of a value close to 0 is a huge
+// negative outlier that decides the correlation on its own
+const shapeLogEpsilon = 1e-9
+
+// reports whether every value is positive and not too close to 0 for its
+// logarithm to be used
+func loggable(values []float64) bool {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if !(v > 0) {
+			return false
+		}
+		low, high = math.Min(low, v), math.Max(high, v)
+	}
+	return low >= shapeLogEpsilon*(high-low)
+}
+
+// picks the model whose linearizing transform of the axes gives the
+// strongest correlation: (x, y) linear, (ln x, y) logarithmic, (x, ln y)
+// exponential and (ln x, ln y) power law. Transforms needing the log of a
+// value that isn't positive, or that is nearly 0 (see shapeLogEpsilon), are
+// left out. Returns the fit of the chosen
+// model, its name and the |r| of each transform tried.
+func SelectByShape(series []Point) (line RegressionLine, selected string, scores map[string]float64) {
+	xs, ys := make([]float64, len(series)), make([]float64, len(series))
+	for i, pt := range series {
+		xs[i], ys[i] = pt.X, pt.Y
+	}
+	positiveX, positiveY := loggable(xs), loggable(ys)
+	transforms := []struct {
+		model  string
+		usable bool
+		tx, ty func(float64) float64
+	}{
+		{"linear", true, identity, identity},
+		{"logarithmic", positiveX, math.Log, identity},
+		{"exponential", positiveY, identity, math.Log},
+		{"powerlaw", positiveX && positiveY, math.Log, math.Log},
+	}
+	scores = make(map[string]float64)
+	selected = "linear"
+	for _, transform := range transforms {
+		if !transform.usable {
+			continue
+		}
+		transformed := make([]Point, len(series))
+		for i, pt := range series {
+			transformed[i] = Point{X: transform.tx(pt.X), Y: transform.ty(pt.Y)}
+		}
+		_, _, _, correlation := LinearRegression(transformed)
+		if math.IsNaN(correlation) {
+			continue
+		}
+		scores[transform.model] = math.Abs(correlation)
+		if scores[transform.model] > scores[selected] {
+			selected = transform.model
+		}
+	}
+
+	// only usable transforms are picked, so this fit can't fail
+	line, _ = FitShape(series, selected)
+	return line, selected, scores
+}
@@ -0,0 +1,38 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSelectByShapeExponential(t *testing.T) {
+	var series []Point
+	for x := 0.0; x < 20; x++ {
+		series = append(series, Point{X: x, Y: 3 * math.Exp(0.4*x)})
+	}
+	line, selected, _ := SelectByShape(series)
+	if selected != "exponential" {
+		t.Fatalf("selected %q, want exponential", selected)
+	}
+	if !closeTo(line.Coefficients[0], 3) || !closeTo(line.Coefficients[1], 0.4) {
+		t.Errorf("coefficients %v, want [3 0.4]", line.Coefficients)
+	}
+}
+
+// y = x - 1, with the zero at x = 1 nudged just above 0: positive, but its
+// log would be an outlier of about -28
+func TestSelectByShapeNearZeroY(t *testing.T) {
+	series := []Point{{X: 1, Y: 1e-12}}
+	for x := 2.0; x <= 10; x++ {
+		series = append(series, Point{X: x, Y: x - 1})
+	}
+	_, selected, scores := SelectByShape(series)
+	for _, model := range []string{"exponential", "powerlaw"} {
+		if _, tried := scores[model]; tried {
+			t.Errorf("%s was tried with y ≈ 0: scores %v", model, scores)
+		}
+	}
+	if selected != "linear" {
+		t.Errorf("selected %q, want linear", selected)
+	}
+}
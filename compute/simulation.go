@@ -0,0 +1,76 @@
+package compute
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// the range of the bootstrapped lines at x
+type EnvelopePoint struct {
+	X     float64 `json:"x"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// lines fitted to bootstrap resamples of a series, and the band they cover
+type Simulation struct {
+	Lines  []RegressionLine `json:"lines"`
+	Points []EnvelopePoint  `json:"envelope"`
+}
+
+// fits resamples lines to bootstrap resamples of the series. At each
+// distinct x of the series the envelope spans the alpha/2 and 1-alpha/2
+// quantiles of the lines' y. Resamples with a single distinct x have no
+// line and are left out.
+func SimulationEnvelope(series []Point, resamples int, alpha float64, seed int64) Simulation {
+	simulation := Simulation{Lines: make([]RegressionLine, 0, resamples), Points: make([]EnvelopePoint, 0)}
+	n := len(series)
+	if n < 2 {
+		return simulation
+	}
+	rng := rand.New(rand.NewSource(seed))
+	resample := make([]Point, n)
+	for b := 0; b < resamples; b++ {
+		for i := range resample {
+			resample[i] = series[rng.Intn(n)]
+		}
+		slope, intercept, stdError, correlation := LinearRegression(resample)
+		if math.IsNaN(slope) || math.IsInf(slope, 0) {
+			continue
+		}
+		line := RegressionLine{Slope: slope,
+			Intercept:   intercept,
+			StdError:    stdError,
+			Correlation: correlation,
+			Equation:    lineEquation("linear", []float64{intercept, slope}, correlation)}
+		line.setCrossings(resample)
+		// a resample can pick points of one y
+		line.ZeroUndefinedCorrelation()
+		simulation.Lines = append(simulation.Lines, line)
+	}
+	if len(simulation.Lines) == 0 {
+		return simulation
+	}
+
+	xs := make([]float64, 0, n)
+	seen := make(map[float64]bool)
+	for _, pt := range series {
+		if !seen[pt.X] {
+			seen[pt.X] = true
+			xs = append(xs, pt.X)
+		}
+	}
+	sort.Float64s(xs)
+	ys := make([]float64, len(simulation.Lines))
+	for _, x := range xs {
+		for i, line := range simulation.Lines {
+			ys[i] = line.Slope*x + line.Intercept
+		}
+		sort.Float64s(ys)
+		simulation.Points = append(simulation.Points, EnvelopePoint{X: x,
+			Lower: Percentile(ys, alpha/2),
+			Upper: Percentile(ys, 1-alpha/2)})
+	}
+	return simulation
+}
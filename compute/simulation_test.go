@@ -0,0 +1,32 @@
+package compute
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSimulationEnvelopeContainsOLS(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	series := make([]Point, 30)
+	for i := range series {
+		x := float64(i)
+		series[i] = Point{X: x, Y: 3*x - 2 + 4*rng.NormFloat64()}
+	}
+	simulation := SimulationEnvelope(series, 200, 0.05, 7)
+	if len(simulation.Lines) != 200 || len(simulation.Points) != 30 {
+		t.Fatalf("got %d lines and %d envelope points, want 200 and 30", len(simulation.Lines), len(simulation.Points))
+	}
+	slope, intercept, _, _ := LinearRegression(series)
+	for _, pt := range simulation.Points {
+		if y := slope*pt.X + intercept; y < pt.Lower || y > pt.Upper {
+			t.Errorf("OLS y = %v at x = %v outside the envelope [%v, %v]", y, pt.X, pt.Lower, pt.Upper)
+		}
+	}
+	if again := SimulationEnvelope(series, 200, 0.05, 7); !reflect.DeepEqual(again, simulation) {
+		t.Error("the same seed gave another envelope")
+	}
+	if empty := SimulationEnvelope(series[:1], 200, 0.05, 7); len(empty.Lines) != 0 || len(empty.Points) != 0 {
+		t.Errorf("one point gave %+v", empty)
+	}
+}
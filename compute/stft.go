@@ -0,0 +1,89 @@
+package compute
+
+import (
+	"math"
+	"sort"
+)
+
+// one column of the spectrogram
+type STFTFrame struct {
+	TimeIndex   int       `json:"timeIndex"`
+	Frequencies []float64 `json:"frequencies"`
+	Magnitudes  []float64 `json:"magnitudes"`
+}
+
+// window functions by name, evaluated at sample i of an n sample window
+var WindowFunctions = map[string]func(i, n int) float64{
+	"hann": func(i, n int) float64 {
+		return 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	},
+	"hamming": func(i, n int) float64 {
+		return 0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/float64(n-1))
+	},
+	"rectangular": func(i, n int) float64 {
+		return 1.0
+	},
+}
+
+// how many frames STFT makes of n points
+func STFTFrameCount(n, windowSize, hopSize int) int {
+	if n < windowSize {
+		return 0
+	}
+	return (n-windowSize)/hopSize + 1
+}
+
+// splits the Y values (ordered by X) into overlapping windows and transforms each one.
+// The sample rate is taken from the mean X spacing; uniform reports whether
+// the spacing was actually constant.
+func STFT(series []Point, windowSize, hopSize int, window func(i, n int) float64) (frames []STFTFrame, uniform bool) {
+	sorted := make([]Point, len(series))
+	copy(sorted, series)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+
+	n := len(sorted)
+	dx := (sorted[n-1].X - sorted[0].X) / float64(n-1)
+	uniform = true
+	for i := 1; i < n; i++ {
+		if math.Abs(sorted[i].X-sorted[i-1].X-dx) > 1e-6*math.Abs(dx) {
+			uniform = false
+			break
+		}
+	}
+
+	bins := windowSize/2 + 1
+	frequencies := make([]float64, bins)
+	for k := range frequencies {
+		if dx != 0 {
+			frequencies[k] = float64(k) / (float64(windowSize) * dx)
+		}
+	}
+
+	frames = make([]STFTFrame, 0)
+	samples := make([]float64, windowSize)
+	for start := 0; start+windowSize <= n; start += hopSize {
+		for i := 0; i < windowSize; i++ {
+			samples[i] = sorted[start+i].Y * window(i, windowSize)
+		}
+		frames = append(frames, STFTFrame{TimeIndex: start,
+			Frequencies: frequencies,
+			Magnitudes:  dftMagnitudes(samples, bins)})
+	}
+	return frames, uniform
+}
+
+// magnitudes of the first bins terms of the discrete Fourier transform
+func dftMagnitudes(samples []float64, bins int) []float64 {
+	n := len(samples)
+	magnitudes := make([]float64, bins)
+	for k := 0; k < bins; k++ {
+		re, im := 0.0, 0.0
+		for i, s := range samples {
+			angle := 2 * math.Pi * float64(k*i) / float64(n)
+			re += s * math.Cos(angle)
+			im -= s * math.Sin(angle)
+		}
+		magnitudes[k] = math.Hypot(re, im)
+	}
+	return magnitudes
+}
@@ -0,0 +1,33 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+// a sine of period 8 samples peaks in bin 64/8 of a 64 sample window, in
+// every frame
+func TestSTFTSineDominantBin(t *testing.T) {
+	series := make([]Point, 512)
+	for i := range series {
+		series[i] = Point{X: float64(i), Y: math.Sin(2 * math.Pi * float64(i) / 8)}
+	}
+	frames, uniform := STFT(series, 64, 32, WindowFunctions["hann"])
+	if !uniform {
+		t.Error("evenly spaced x reported as non-uniform")
+	}
+	if len(frames) != STFTFrameCount(len(series), 64, 32) {
+		t.Errorf("got %d frames, STFTFrameCount says %d", len(frames), STFTFrameCount(len(series), 64, 32))
+	}
+	for _, frame := range frames {
+		dominant := 0
+		for k, magnitude := range frame.Magnitudes {
+			if magnitude > frame.Magnitudes[dominant] {
+				dominant = k
+			}
+		}
+		if dominant != 8 || frame.Frequencies[dominant] != 0.125 {
+			t.Errorf("frame at %d peaks in bin %d (%v), want 8 (0.125)", frame.TimeIndex, dominant, frame.Frequencies[dominant])
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package compute
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrSameX = errors.New("all x values are the same")
+var ErrSameY = errors.New("all y values are the same")
+
+// the sums a least squares line can be fitted from, without the points
+type RegressionSums struct {
+	N     int     `json:"n"`
+	SumX  float64 `json:"sumX"`
+	SumY  float64 `json:"sumY"`
+	SumXY float64 `json:"sumXY"`
+	SumX2 float64 `json:"sumX2"`
+	SumY2 float64 `json:"sumY2"`
+}
+
+// the line LinearRegression fits to points with these sums
+func LineFromSums(sums RegressionSums) (RegressionLine, error) {
+	n := float64(sums.N)
+	sxx := sums.SumX2 - sums.SumX*sums.SumX/n
+	syy := sums.SumY2 - sums.SumY*sums.SumY/n
+	sxy := sums.SumXY - sums.SumX*sums.SumY/n
+	if sxx <= 0 {
+		return RegressionLine{}, &RegressionError{Model: "linear", Err: ErrSameX}
+	}
+	slope := sxy / sxx
+	ymean := sums.SumY / n
+	intercept := ymean - slope*sums.SumX/n
+	// the residual sum of squares, which rounding can take below 0
+	sr := math.Max(syy-slope*sxy, 0)
+	correlation := math.Sqrt((syy - sr) / syy)
+	line := RegressionLine{Slope: slope,
+		Intercept:     intercept,
+		StdError:      math.Sqrt(sr / (n - 2)),
+		Correlation:   correlation,
+		FittedAtMeanX: ymean,
+		Equation:      lineEquation("linear", []float64{intercept, slope}, correlation)}
+	if slope == 0 {
+		line.XInterceptUndefined = true
+	} else {
+		line.XIntercept = -intercept / slope
+	}
+	return line, nil
+}
@@ -0,0 +1,126 @@
+package compute
+
+import (
+	"math"
+	"sort"
+)
+
+// thinning methods by name, each reducing a series sorted by X to at most
+// threshold points
+var ThinMethods = map[string]func(series []Point, threshold int) []Point{
+	"largestTriangle": LTTB,
+	"nthPoint":        NthPoint,
+	"minMax":          MinMax,
+}
+
+// the result of thinning a series for plotting
+type Thinned struct {
+	Envelope
+	Series []Point `json:"series"`
+	// thinned points over original points
+	ReductionRatio float64 `json:"reductionRatio"`
+}
+
+// thins a copy of the series, ordered by X, with the named method
+func Thin(series []Point, threshold int, method func([]Point, int) []Point) *Thinned {
+	sorted := make([]Point, len(series))
+	copy(sorted, series)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].X < sorted[j].X })
+	thinned := &Thinned{Envelope: NewEnvelope(), Series: sorted}
+	if len(sorted) > threshold {
+		thinned.Series = method(sorted, threshold)
+	}
+	if len(series) > 0 {
+		thinned.ReductionRatio = float64(len(thinned.Series)) / float64(len(series))
+	}
+	return thinned
+}
+
+// Largest Triangle Three Buckets downsampling (Steinarsson, 2013). Keeps the
+// first and last points and from each bucket in between the point making the
+// largest triangle with the previous pick and the next bucket's average.
+func LTTB(series []Point, threshold int) []Point {
+	if threshold >= len(series) || threshold < 3 {
+		return series
+	}
+	thinned := make([]Point, 0, threshold)
+	thinned = append(thinned, series[0])
+	bucketSize := float64(len(series)-2) / float64(threshold-2)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		start := int(float64(i)*bucketSize) + 1
+		end := int(float64(i+1)*bucketSize) + 1
+
+		// average of the next bucket, or the last point for the last bucket
+		nextStart, nextEnd := end, int(float64(i+2)*bucketSize)+1
+		if nextEnd > len(series) {
+			nextEnd = len(series)
+		}
+		avgX, avgY := 0.0, 0.0
+		for _, pt := range series[nextStart:nextEnd] {
+			avgX += pt.X
+			avgY += pt.Y
+		}
+		avgX /= float64(nextEnd - nextStart)
+		avgY /= float64(nextEnd - nextStart)
+
+		maxArea, pick := -1.0, start
+		for j := start; j < end; j++ {
+			area := math.Abs((series[a].X-avgX)*(series[j].Y-series[a].Y) -
+				(series[a].X-series[j].X)*(avgY-series[a].Y))
+			if area > maxArea {
+				maxArea, pick = area, j
+			}
+		}
+		thinned = append(thinned, series[pick])
+		a = pick
+	}
+	return append(thinned, series[len(series)-1])
+}
+
+// keeps every n-th point, n chosen to leave at most threshold points
+func NthPoint(series []Point, threshold int) []Point {
+	if threshold >= len(series) || threshold < 1 {
+		return series
+	}
+	n := (len(series) + threshold - 1) / threshold
+	thinned := make([]Point, 0, threshold)
+	for i := 0; i < len(series); i += n {
+		thinned = append(thinned, series[i])
+	}
+	return thinned
+}
+
+// splits the series into threshold/2 buckets and keeps the lowest and the
+// highest point of each, in X order, so peaks survive
+func MinMax(series []Point, threshold int) []Point {
+	buckets := threshold / 2
+	if threshold >= len(series) || buckets < 1 {
+		return series
+	}
+	bucketSize := float64(len(series)) / float64(buckets)
+	thinned := make([]Point, 0, 2*buckets)
+	for i := 0; i < buckets; i++ {
+		start, end := int(float64(i)*bucketSize), int(float64(i+1)*bucketSize)
+		if end <= start {
+			continue
+		}
+		lo, hi := start, start
+		for j := start; j < end; j++ {
+			if series[j].Y < series[lo].Y {
+				lo = j
+			}
+			if series[j].Y > series[hi].Y {
+				hi = j
+			}
+		}
+		if lo > hi {
+			lo, hi = hi, lo
+		}
+		thinned = append(thinned, series[lo])
+		if hi != lo {
+			thinned = append(thinned, series[hi])
+		}
+	}
+	return thinned
+}
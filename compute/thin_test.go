@@ -0,0 +1,52 @@
+package compute
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// trapezoidal area under a series sorted by X
+func areaUnderCurve(series []Point) float64 {
+	area := 0.0
+	for i := 1; i < len(series); i++ {
+		area += (series[i].X - series[i-1].X) * (series[i].Y + series[i-1].Y) / 2
+	}
+	return area
+}
+
+func TestLTTBKeepsShape(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	series := make([]Point, 100000)
+	for i := range series {
+		x := float64(i)
+		series[i] = Point{X: x, Y: 100 + 50*math.Sin(x/5000) + rng.NormFloat64()}
+	}
+
+	thinned := Thin(series, 500, LTTB)
+	if len(thinned.Series) != 500 || thinned.ReductionRatio != 0.005 {
+		t.Errorf("got %d points, ratio %v, want 500 and 0.005", len(thinned.Series), thinned.ReductionRatio)
+	}
+	if thinned.Series[0] != series[0] || thinned.Series[499] != series[len(series)-1] {
+		t.Error("first or last point dropped")
+	}
+	want, got := areaUnderCurve(series), areaUnderCurve(thinned.Series)
+	if math.Abs(got-want)/want > 0.01 {
+		t.Errorf("area under the thinned curve %v, want within 1%% of %v", got, want)
+	}
+}
+
+func TestThinMethodsRespectThreshold(t *testing.T) {
+	series := make([]Point, 1001)
+	for i := range series {
+		series[i] = Point{X: float64(i), Y: float64(i % 17)}
+	}
+	for name, method := range ThinMethods {
+		if thinned := Thin(series, 100, method); len(thinned.Series) > 100 {
+			t.Errorf("%s kept %d points, want at most 100", name, len(thinned.Series))
+		}
+		if thinned := Thin(series[:50], 100, method); len(thinned.Series) != 50 {
+			t.Errorf("%s thinned a series under the threshold to %d points", name, len(thinned.Series))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package compute
+
+import "math"
+
+// about n round-numbered tick values spanning min..max, by Heckbert's
+// "nice numbers" algorithm (Graphics Gems, 1990). The first and last tick
+// enclose the range, so there may be a tick or two more than asked for.
+func NiceTicks(min, max float64, n int) []float64 {
+	if n < 2 || math.IsNaN(min) || math.IsNaN(max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return nil
+	}
+	if max == min {
+		return []float64{min}
+	}
+	if max < min {
+		min, max = max, min
+	}
+	step := niceNumber(niceNumber(max-min, false)/float64(n-1), true)
+	first := math.Floor(min/step) * step
+	last := math.Ceil(max/step) * step
+	// round away the error the steps pick up, to the step's decimal places
+	scale := math.Pow(10, math.Max(0, -math.Floor(math.Log10(step))))
+	ticks := make([]float64, 0, n+2)
+	for i := 0; first+float64(i)*step <= last+step/2; i++ {
+		ticks = append(ticks, math.Round((first+float64(i)*step)*scale)/scale)
+	}
+	return ticks
+}
+
+// a number of the form 1, 2 or 5 times a power of 10 near x; rounded to
+// the nearest one, or else the smallest not below x
+func niceNumber(x float64, round bool) float64 {
+	exponent := math.Floor(math.Log10(x))
+	fraction := x / math.Pow(10, exponent)
+	var nice float64
+	switch {
+	case round && fraction < 1.5, !round && fraction <= 1:
+		nice = 1
+	case round && fraction < 3, !round && fraction <= 2:
+		nice = 2
+	case round && fraction < 7, !round && fraction <= 5:
+		nice = 5
+	default:
+		nice = 10
+	}
+	return nice * math.Pow(10, exponent)
+}
+
+// the X and Y ranges of a series
+func SeriesBounds(series []Point) (xmin, xmax, ymin, ymax float64) {
+	xmin, ymin = math.Inf(1), math.Inf(1)
+	xmax, ymax = math.Inf(-1), math.Inf(-1)
+	for _, pt := range series {
+		xmin, xmax = math.Min(xmin, pt.X), math.Max(xmax, pt.X)
+		ymin, ymax = math.Min(ymin, pt.Y), math.Max(ymax, pt.Y)
+	}
+	return xmin, xmax, ymin, ymax
+}
+
+// the X and Y ranges a chart of a series shows
+type AxisBounds struct {
+	XMin float64 `json:"xmin" xml:"xmin"`
+	XMax float64 `json:"xmax" xml:"xmax"`
+	YMin float64 `json:"ymin" xml:"ymin"`
+	YMax float64 `json:"ymax" xml:"ymax"`
+}
+
+// the bounds of the series widened on each side by pad times their width,
+// so no point sits on the chart's edge. A range of no width is widened by
+// pad times its value instead, or by pad when that is 0.
+func PaddedBounds(series []Point, pad float64) AxisBounds {
+	xmin, xmax, ymin, ymax := SeriesBounds(series)
+	bounds := AxisBounds{}
+	bounds.XMin, bounds.XMax = padRange(xmin, xmax, pad)
+	bounds.YMin, bounds.YMax = padRange(ymin, ymax, pad)
+	return bounds
+}
+
+func padRange(min, max, pad float64) (float64, float64) {
+	width := max - min
+	if width == 0 {
+		if width = math.Abs(min); width == 0 {
+			width = 1
+		}
+	}
+	return min - pad*width, max + pad*width
+}
@@ -0,0 +1,46 @@
+package compute
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		min, max float64
+		n        int
+		want     []float64
+	}{
+		{0, 97, 5, []float64{0, 20, 40, 60, 80, 100}},
+		{0.13, 0.87, 5, []float64{0, 0.2, 0.4, 0.6, 0.8, 1}},
+		{-3.7, 12.1, 6, []float64{-5, 0, 5, 10, 15}},
+		{12.1, -3.7, 6, []float64{-5, 0, 5, 10, 15}},
+		{1000, 1003, 4, []float64{1000, 1002, 1004}},
+		{5, 5, 5, []float64{5}},
+		{0, 1, 1, nil},
+	}
+	for _, test := range tests {
+		if got := NiceTicks(test.min, test.max, test.n); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v..%v in %d: got %v, want %v", test.min, test.max, test.n, got, test.want)
+		}
+	}
+}
+
+func TestPaddedBounds(t *testing.T) {
+	series := []Point{{X: 2, Y: 8}, {X: 0, Y: 0}, {X: 4, Y: 4}}
+	if got, want := PaddedBounds(series, 0.25), (AxisBounds{XMin: -1, XMax: 5, YMin: -2, YMax: 10}); got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	if got, want := PaddedBounds(series, 0), (AxisBounds{XMin: 0, XMax: 4, YMin: 0, YMax: 8}); got != want {
+		t.Errorf("no padding: got %+v, want %+v", got, want)
+	}
+	// a flat line is padded by its value, and a line on 0 by pad itself
+	flat := []Point{{X: 0, Y: 4}, {X: 4, Y: 4}}
+	if got, want := PaddedBounds(flat, 0.25), (AxisBounds{XMin: -1, XMax: 5, YMin: 3, YMax: 5}); got != want {
+		t.Errorf("flat: got %+v, want %+v", got, want)
+	}
+	zero := []Point{{X: 0, Y: 0}, {X: 4, Y: 0}}
+	if got, want := PaddedBounds(zero, 0.25), (AxisBounds{XMin: -1, XMax: 5, YMin: -0.25, YMax: 0.25}); got != want {
+		t.Errorf("zero: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package compute
+
+// how a fit of new data compares to an earlier one
+type Trend struct {
+	SlopeDelta       float64 `json:"slopeDelta"`
+	CorrelationDelta float64 `json:"correlationDelta"`
+	StdErrorDelta    float64 `json:"stdErrorDelta"`
+	PointCountDelta  int     `json:"pointCountDelta"`
+	// "improving" when the correlation went up and the standard error
+	// down, "degrading" for the opposite and "stable" otherwise
+	TrendDirection string `json:"trendDirection"`
+}
+
+// compares the fit of latestCount points to the previous fit of
+// previousCount points
+func CompareFits(previous RegressionLine, previousCount int, latest RegressionLine, latestCount int) Trend {
+	trend := Trend{SlopeDelta: latest.Slope - previous.Slope,
+		CorrelationDelta: latest.Correlation - previous.Correlation,
+		StdErrorDelta:    latest.StdError - previous.StdError,
+		PointCountDelta:  latestCount - previousCount,
+		TrendDirection:   "stable"}
+	if trend.CorrelationDelta > 0 && trend.StdErrorDelta < 0 {
+		trend.TrendDirection = "improving"
+	} else if trend.CorrelationDelta < 0 && trend.StdErrorDelta > 0 {
+		trend.TrendDirection = "degrading"
+	}
+	return trend
+}
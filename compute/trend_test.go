@@ -0,0 +1,25 @@
+package compute
+
+import "testing"
+
+func TestCompareFits(t *testing.T) {
+	previous := RegressionLine{Slope: 2, Correlation: 0.5, StdError: 1}
+	tests := []struct {
+		latest RegressionLine
+		want   Trend
+	}{
+		{RegressionLine{Slope: 2.5, Correlation: 0.75, StdError: 0.5},
+			Trend{SlopeDelta: 0.5, CorrelationDelta: 0.25, StdErrorDelta: -0.5, PointCountDelta: 5, TrendDirection: "improving"}},
+		{RegressionLine{Slope: 1, Correlation: 0.25, StdError: 1.5},
+			Trend{SlopeDelta: -1, CorrelationDelta: -0.25, StdErrorDelta: 0.5, PointCountDelta: 5, TrendDirection: "degrading"}},
+		// better correlation but more scatter is neither
+		{RegressionLine{Slope: 2, Correlation: 0.75, StdError: 1.5},
+			Trend{CorrelationDelta: 0.25, StdErrorDelta: 0.5, PointCountDelta: 5, TrendDirection: "stable"}},
+		{previous, Trend{PointCountDelta: 5, TrendDirection: "stable"}},
+	}
+	for _, test := range tests {
+		if got := CompareFits(previous, 10, test.latest, 15); got != test.want {
+			t.Errorf("from %+v to %+v: got %+v, want %+v", previous, test.latest, got, test.want)
+		}
+	}
+}
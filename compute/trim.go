@@ -0,0 +1,30 @@
+package compute
+
+import (
+	"math"
+	"sort"
+)
+
+// the largest trim fraction TrimmedFit accepts
+const MaxTrim = 0.2
+
+// fits the series, drops the fraction of points with the largest absolute
+// residuals and fits the rest. At least 2 points are always kept. Returns
+// the refitted line, the initial one and how many points were dropped.
+func TrimmedFit(series []Point, fraction float64) (trimmedLine RegressionLine, initial RegressionLine, trimmed int) {
+	initial = FitLine(series)
+	trimmed = int(math.Floor(fraction * float64(len(series))))
+	if len(series)-trimmed < 2 {
+		trimmed = len(series) - 2
+	}
+	if trimmed <= 0 {
+		return initial, initial, 0
+	}
+
+	kept := make([]Point, len(series))
+	copy(kept, series)
+	residual := func(pt Point) float64 { return math.Abs(pt.Y - (initial.Slope*pt.X + initial.Intercept)) }
+	sort.SliceStable(kept, func(i, j int) bool { return residual(kept[i]) < residual(kept[j]) })
+	kept = kept[:len(kept)-trimmed]
+	return FitLine(kept), initial, trimmed
+}
@@ -0,0 +1,36 @@
+package compute
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrimmedFitDropsOutlierCluster(t *testing.T) {
+	// y = 2x + 1 and a cluster of two points far above it
+	var series []Point
+	for x := 0.0; x < 20; x++ {
+		series = append(series, Point{X: x, Y: 2*x + 1})
+	}
+	series = append(series, Point{X: 5, Y: 200}, Point{X: 6, Y: 210})
+
+	line, initial, trimmed := TrimmedFit(series, 0.1)
+	if trimmed != 2 {
+		t.Errorf("trimmed %d points, want 2", trimmed)
+	}
+	if !closeTo(line.Slope, 2) || !closeTo(line.Intercept, 1) {
+		t.Errorf("trimmed fit y = %vx + %v, want y = 2x + 1", line.Slope, line.Intercept)
+	}
+	if math.Abs(initial.Intercept-1) < 10 {
+		t.Errorf("initial intercept %v, want it pulled up by the cluster", initial.Intercept)
+	}
+}
+
+func TestTrimmedFitKeepsTwoPoints(t *testing.T) {
+	series := []Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 5}}
+	if _, _, trimmed := TrimmedFit(series, 0.9); trimmed != 1 {
+		t.Errorf("trimmed %d of 3 points, want 1", trimmed)
+	}
+	if _, _, trimmed := TrimmedFit(series[:2], MaxTrim); trimmed != 0 {
+		t.Errorf("trimmed %d of 2 points", trimmed)
+	}
+}
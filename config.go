@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"goplot/compute"
+	"goplot/httplog"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// prefix of the environment variables overriding config file settings
+const envPrefix = "GOPLOT_"
+
+func defaultConfig() Config {
+	return Config{Address: addressFlagDefault,
+		CustomLog:             "nolog",
+		DataDir:               "data",
+		MaxBootstrap:          10000,
+		MaxPoints:             compute.MAXLINES,
+		DensityGridSize:       50,
+		AxisPadding:           0.05,
+		UDPSeries:             "udp",
+		UDPFlushIntervalMs:    1000,
+		SessionTTL:            "1h",
+		DefaultResponseFormat: "json",
+		QualityWeights:        compute.DefaultQualityWeights,
+		LogSampleRate:         1.0,
+		MaxLADIterations:      50,
+		MaxResponseBytes:      64 << 20,
+		EquationPrecision:     compute.EquationPrecision,
+		PredictionLevel:       0.95,
+		MaxPredictions:        1000,
+		AnalyticsBufferSize:   10000,
+		StaleAfterMinutes:     60,
+		SeriesNameMaxLen:      64,
+		SeriesNamePattern:     "^[a-zA-Z0-9_-]+$",
+		LogBufferSize:         httplog.DefaultBufferSize,
+		LogFlushMs:            int(httplog.DefaultFlushInterval / time.Millisecond)}
+}
+
+// Builds the effective configuration. Settings are taken, from lowest to
+// highest precedence, from the defaults, the config file at path, the
+// environment and finally command line flags that were explicitly given.
+//
+// Every Config field can be set from the environment as GOPLOT_ followed
+// by the upper-cased field name, e.g. GOPLOT_ADDRESS, GOPLOT_CUSTOMLOG or
+// GOPLOT_DATADIR. Strings, numbers and booleans are given as plain values;
+// lists, maps and structs (GOPLOT_LOGFORMAT, GOPLOT_SERIESACL, ...) as JSON.
+//
+// With a RemoteConfigURL, from the config file or the environment, the
+// settings fetched from it go between the defaults and the config file.
+// When it can't be fetched a warning is printed and the local settings are
+// used alone.
+//
+// A config file that can't be read is reported as an *os.PathError, one
+// with bad settings as a *ConfigError.
+func LoadConfig(path string) (config Config, err error) {
+	config = defaultConfig()
+
+	configJsonBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if err = json.Unmarshal(configJsonBytes, &config); err != nil {
+		return config, &ConfigError{Err: err}
+	}
+	if err = overlayEnv(&config); err != nil {
+		return config, err
+	}
+	if url, ok := os.LookupEnv("GOPLOT_REMOTE_CONFIG_URL"); ok {
+		config.RemoteConfigURL = url
+	}
+	if config.RemoteConfigURL != "" {
+		merged := defaultConfig()
+		if err := fetchRemoteConfig(config.RemoteConfigURL, &merged); err != nil {
+			fmt.Fprintf(os.Stderr, "remote config from %s unavailable, using local config only: %s\n", config.RemoteConfigURL, err.Error())
+		} else {
+			// these went through once already
+			json.Unmarshal(configJsonBytes, &merged)
+			overlayEnv(&merged)
+			merged.RemoteConfigURL = config.RemoteConfigURL
+			config = merged
+		}
+	}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "l":
+			config.Address = f.Value.String()
+		}
+	})
+
+	if _, ok := formatContentTypes[config.DefaultResponseFormat]; !ok {
+		return config, &ConfigError{Field: "DefaultResponseFormat", Err: fmt.Errorf("unknown format %s", strconv.Quote(config.DefaultResponseFormat))}
+	}
+	for _, format := range config.AllowedFormats {
+		if _, ok := formatContentTypes[format]; !ok {
+			return config, &ConfigError{Field: "AllowedFormats", Err: fmt.Errorf("unknown format %s", strconv.Quote(format))}
+		}
+	}
+	if !formatAllowed(config.DefaultResponseFormat, config.AllowedFormats) {
+		return config, &ConfigError{Field: "DefaultResponseFormat", Err: errors.New("not in AllowedFormats")}
+	}
+	if config.MaxBytesPerMinutePerIP < 0 {
+		return config, &ConfigError{Field: "MaxBytesPerMinutePerIP", Err: errors.New("must not be negative")}
+	}
+	if config.StaleAfterMinutes < 1 {
+		return config, &ConfigError{Field: "StaleAfterMinutes", Err: errors.New("must be positive")}
+	}
+	if config.AnalyticsBufferSize < 1 {
+		return config, &ConfigError{Field: "AnalyticsBufferSize", Err: errors.New("must be positive")}
+	}
+	if config.MaxPredictions < 1 {
+		return config, &ConfigError{Field: "MaxPredictions", Err: errors.New("must be positive")}
+	}
+	if config.AxisPadding < 0 || config.AxisPadding > 1 {
+		return config, &ConfigError{Field: "AxisPadding", Err: errBadPad}
+	}
+	for _, token := range config.LogFormat {
+		if _, ok := logTokens[token]; !ok {
+			return config, &ConfigError{Field: "LogFormat", Err: fmt.Errorf("unknown token %s", strconv.Quote(token))}
+		}
+	}
+	if config.BasePath != "" && (!strings.HasPrefix(config.BasePath, "/") || strings.HasSuffix(config.BasePath, "/")) {
+		return config, &ConfigError{Field: "BasePath", Err: errors.New("must start with / and not end with one")}
+	}
+	if ttl, err := time.ParseDuration(config.SessionTTL); err != nil || ttl <= 0 {
+		return config, &ConfigError{Field: "SessionTTL", Err: fmt.Errorf("not a positive duration: %s", strconv.Quote(config.SessionTTL))}
+	}
+	if config.SeriesNamePattern == "" {
+		return config, &ConfigError{Field: "SeriesNamePattern", Err: errors.New("must not be empty")}
+	}
+	pattern, err := regexp.Compile(config.SeriesNamePattern)
+	if err != nil {
+		return config, &ConfigError{Field: "SeriesNamePattern", Err: err}
+	}
+	if config.UDPAddr != "" {
+		if len(config.UDPSeries) > config.SeriesNameMaxLen || !pattern.MatchString(config.UDPSeries) {
+			return config, &ConfigError{Field: "UDPSeries", Err: errBadSeriesName}
+		}
+		if config.UDPFlushIntervalMs <= 0 {
+			return config, &ConfigError{Field: "UDPFlushIntervalMs", Err: errors.New("must be positive")}
+		}
+	}
+	return config, nil
+}
+
+// how long fetching Config.RemoteConfigURL may take
+const remoteConfigTimeout = 10 * time.Second
+
+// sets the config from the JSON served at url
+func fetchRemoteConfig(url string, config *Config) error {
+	client := &http.Client{Timeout: remoteConfigTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("got %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(config)
+}
+
+// sets each Config field that has a GOPLOT_<FIELD> environment variable
+func overlayEnv(config *Config) error {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value, ok := os.LookupEnv(envPrefix + strings.ToUpper(name))
+		if !ok {
+			continue
+		}
+		field := v.Field(i)
+		var err error
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int, reflect.Int64:
+			var n int64
+			n, err = strconv.ParseInt(value, 10, 64)
+			field.SetInt(n)
+		case reflect.Float64:
+			var f float64
+			f, err = strconv.ParseFloat(value, 64)
+			field.SetFloat(f)
+		case reflect.Bool:
+			var b bool
+			b, err = strconv.ParseBool(value)
+			field.SetBool(b)
+		default:
+			err = json.Unmarshal([]byte(value), field.Addr().Interface())
+		}
+		if err != nil {
+			return &ConfigError{Field: name, Err: fmt.Errorf("%s%s: %s", envPrefix, strings.ToUpper(name), err.Error())}
+		}
+	}
+	return nil
+}
+
+// printed in place of secrets by Config.String
+const redacted = "***"
+
+// the effective settings, one per line, for the startup log. AdminSecret
+// and the API keys of SeriesACL are secrets and are masked; the prefixes
+// the keys grant are kept.
+func (config *Config) String() string {
+	var out strings.Builder
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		value := fmt.Sprintf("%v", v.Field(i).Interface())
+		if name == "AdminSecret" && config.AdminSecret != "" {
+			value = redacted
+		}
+		if name == "SeriesACL" {
+			grants := make([]string, 0, len(config.SeriesACL))
+			for _, prefixes := range config.SeriesACL {
+				grants = append(grants, fmt.Sprintf("%s:%v", redacted, prefixes))
+			}
+			sort.Strings(grants)
+			value = "map[" + strings.Join(grants, " ") + "]"
+		}
+		fmt.Fprintf(&out, "%s: %s\n", name, value)
+	}
+	return out.String()
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writes configJSON to a config file and returns its path
+func writeConfig(t *testing.T, configJSON string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "goplot.conf")
+	if err := os.WriteFile(path, []byte(configJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, `{"Address": "127.0.0.1:1001", "DataDir": "from-file", "MaxBootstrap": 10, "CustomLog": "file.log"}`)
+	t.Setenv("GOPLOT_ADDRESS", "127.0.0.1:1002")
+	t.Setenv("GOPLOT_MAXBOOTSTRAP", "20")
+	t.Setenv("GOPLOT_SERIESACL", `{"key": ["prefix-"]}`)
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// env over file over defaults
+	if loaded.Address != "127.0.0.1:1002" || loaded.MaxBootstrap != 20 {
+		t.Errorf("env didn't override the file: Address %s, MaxBootstrap %d", loaded.Address, loaded.MaxBootstrap)
+	}
+	if loaded.DataDir != "from-file" || loaded.CustomLog != "file.log" {
+		t.Errorf("file didn't override the defaults: DataDir %s, CustomLog %s", loaded.DataDir, loaded.CustomLog)
+	}
+	if loaded.DensityGridSize != defaultConfig().DensityGridSize {
+		t.Errorf("DensityGridSize %d, want the default", loaded.DensityGridSize)
+	}
+	if want := map[string][]string{"key": {"prefix-"}}; !reflect.DeepEqual(loaded.SeriesACL, want) {
+		t.Errorf("SeriesACL %v, want %v from JSON in the environment", loaded.SeriesACL, want)
+	}
+
+	// a flag given on the command line beats the environment
+	saved := flag.CommandLine
+	defer func() { flag.CommandLine = saved }()
+	flag.CommandLine = flag.NewFlagSet("goplot", flag.ContinueOnError)
+	flag.CommandLine.String("l", addressFlagDefault, "")
+	flag.CommandLine.Parse([]string{"-l", "127.0.0.1:1003"})
+	if loaded, err = LoadConfig(path); err != nil || loaded.Address != "127.0.0.1:1003" {
+		t.Errorf("with -l: Address %s (%v), want the flag's", loaded.Address, err)
+	}
+}
+
+func TestLoadConfigBadEnv(t *testing.T) {
+	t.Setenv("GOPLOT_MAXBOOTSTRAP", "lots")
+	var configError *ConfigError
+	if _, err := LoadConfig(writeConfig(t, `{}`)); !errors.As(err, &configError) || configError.Field != "MaxBootstrap" {
+		t.Errorf("got %v, want a MaxBootstrap ConfigError", err)
+	}
+}
+
+func TestConfigStringRedacts(t *testing.T) {
+	redactedConfig := defaultConfig()
+	redactedConfig.Address = "127.0.0.1:7070"
+	redactedConfig.AdminSecret = "hunter2"
+	redactedConfig.SeriesACL = map[string][]string{"alice-secret-key": {"alice-"}}
+	out := redactedConfig.String()
+	for _, want := range []string{"Address: 127.0.0.1:7070\n", "AdminSecret: ***\n", "SeriesACL: map[***:[alice-]]\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, secret := range []string{"hunter2", "alice-secret-key"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("%q shown in:\n%s", secret, out)
+		}
+	}
+}
+
+func TestLoadConfigAllowedFormats(t *testing.T) {
+	tests := []struct {
+		configJSON string
+		wantField  string
+	}{
+		{`{"AllowedFormats": ["json", "csv"], "DefaultResponseFormat": "csv"}`, ""},
+		{`{"AllowedFormats": ["json", "png"]}`, "AllowedFormats"},
+		{`{"AllowedFormats": ["csv"], "DefaultResponseFormat": "json"}`, "DefaultResponseFormat"},
+	}
+	for _, test := range tests {
+		_, err := LoadConfig(writeConfig(t, test.configJSON))
+		var configError *ConfigError
+		if test.wantField == "" && err != nil {
+			t.Errorf("%s: %v", test.configJSON, err)
+		} else if test.wantField != "" && (!errors.As(err, &configError) || configError.Field != test.wantField) {
+			t.Errorf("%s: got %v, want an error in %s", test.configJSON, err, test.wantField)
+		}
+	}
+}
+
+func TestLoadConfigRemote(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		c.Write([]byte(`{"Address": "127.0.0.1:2001", "DataDir": "from-remote", "MaxBootstrap": 77}`))
+	}))
+	defer remote.Close()
+	path := writeConfig(t, `{"Address": "127.0.0.1:2002", "CustomLog": "nolog"}`)
+	t.Setenv("GOPLOT_REMOTE_CONFIG_URL", remote.URL)
+
+	loaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the file wins over the remote, the remote over the defaults
+	if loaded.Address != "127.0.0.1:2002" || loaded.DataDir != "from-remote" || loaded.MaxBootstrap != 77 || loaded.CustomLog != "nolog" {
+		t.Errorf("got Address %s, DataDir %s, MaxBootstrap %d and CustomLog %s",
+			loaded.Address, loaded.DataDir, loaded.MaxBootstrap, loaded.CustomLog)
+	}
+	if loaded.RemoteConfigURL != remote.URL {
+		t.Errorf("RemoteConfigURL %q", loaded.RemoteConfigURL)
+	}
+
+	// unreachable: the local config alone
+	remote.Close()
+	if loaded, err = LoadConfig(path); err != nil || loaded.DataDir != defaultConfig().DataDir || loaded.Address != "127.0.0.1:2002" {
+		t.Errorf("without the remote got DataDir %s, Address %s, %v", loaded.DataDir, loaded.Address, err)
+	}
+}
+
+func TestLoadConfigLogFormat(t *testing.T) {
+	if _, err := LoadConfig(writeConfig(t, `{"LogFormat": ["RemoteHost", "Status"]}`)); err != nil {
+		t.Errorf("known tokens: %v", err)
+	}
+	_, err := LoadConfig(writeConfig(t, `{"LogFormat": ["RemoteHost", "Bogus"]}`))
+	var configError *ConfigError
+	if !errors.As(err, &configError) || configError.Field != "LogFormat" || !strings.Contains(err.Error(), `"Bogus"`) {
+		t.Errorf("got %v, want a LogFormat error naming the token", err)
+	}
+}
@@ -1,8 +1,20 @@
 package constants
 
+// process exit codes, one per way goplot can fail to start
 const (
-	EXIT_SUCCESS      = 0
-	EXIT_NO_CONFIG    // config file not found or couldn't be read
-	EXIT_CONFIG_PARSE // failed to parse the config file
-	EXIT_CANT_LISTEN
+	EXIT_SUCCESS          = iota
+	EXIT_NO_CONFIG        // 1: config file not found or couldn't be read
+	EXIT_CONFIG_PARSE     // 2: failed to parse the config file, or a setting is invalid
+	EXIT_CANT_LISTEN      // 3: couldn't listen on the HTTP or UDP address, or serving failed
+	EXIT_SELF_TEST_FAILED // 4: the regression self-test gave wrong results
+	EXIT_INIT_FAILED      // 5: goplot init couldn't write the config file
+	EXIT_DATA_DIR_ERROR   // 6: DataDir can't be created or written to
+	// 7: the TLS certificate or key couldn't be loaded. Reserved: goplot
+	// doesn't serve TLS yet.
+	EXIT_TLS_ERROR
+	// 8: the access log couldn't be opened. Reserved: that is a warning for
+	// now, and goplot serves without an access log.
+	EXIT_LOG_OPEN_ERROR
+	// 9: a plugin couldn't be loaded. Reserved: goplot has no plugins yet.
+	EXIT_PLUGIN_LOAD_ERROR
 )
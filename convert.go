@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errUnknownConversion = errors.New("from and to must be csv and json, one each")
+
+// converts a CSV body to a JSON array of objects, keyed by the header row's
+// names or else col0, col1, ..., or such a JSON array back to CSV. Numbers
+// keep their exact text either way, so converting back gives the same data.
+// POST /goplot/convert?from=csv&to=json
+// POST /goplot/convert?from=json&to=csv
+func convertServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	query := req.URL.Query()
+	body := io.LimitReader(req.Body, maxUploadBytes)
+	var err error
+	switch from, to := query.Get("from"), query.Get("to"); {
+	case from == "csv" && to == "json":
+		c.Header().Set("Content-Type", formatContentTypes["json"])
+		err = csvToJSON(c, body)
+	case from == "json" && to == "csv":
+		c.Header().Set("Content-Type", formatContentTypes["csv"])
+		err = jsonToCSV(c, body)
+	default:
+		err = errUnknownConversion
+	}
+	if err != nil {
+		c.Header().Del("Content-Type")
+		serveErrorFor(c, req, &compute.ParseError{Err: err})
+	}
+}
+
+// default key of the column at index i
+func columnKey(i int) string {
+	return "col" + strconv.Itoa(i)
+}
+
+// a field as a JSON number when it is one, a string otherwise. NaN, Inf
+// and the like parse as floats but aren't JSON numbers.
+func fieldValue(field string) interface{} {
+	number := strings.TrimSpace(field)
+	if _, err := strconv.ParseFloat(number, 64); err == nil && json.Valid([]byte(number)) {
+		return json.Number(number)
+	}
+	return field
+}
+
+// converts the CSV, writing nothing unless it all parses
+func csvToJSON(w io.Writer, r io.Reader) error {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return err
+	}
+	var names []string
+	if len(records) > 0 && compute.IsHeader(records[0]) {
+		names, records = records[0], records[1:]
+	}
+	rows := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		// written field by field to keep the column order
+		var row strings.Builder
+		row.WriteString("{")
+		for i, field := range record {
+			key := columnKey(i)
+			if i < len(names) {
+				key = strings.TrimSpace(names[i])
+			}
+			jsonKey, _ := json.Marshal(key)
+			jsonValue, err := json.Marshal(fieldValue(field))
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				row.WriteString(",")
+			}
+			row.Write(jsonKey)
+			row.WriteString(":")
+			row.Write(jsonValue)
+		}
+		row.WriteString("}")
+		rows = append(rows, json.RawMessage(row.String()))
+	}
+	return json.NewEncoder(w).Encode(rows)
+}
+
+// the keys of a JSON object in the order given, and its values
+func orderedObject(raw json.RawMessage) (keys []string, values map[string]interface{}, err error) {
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, nil, errors.New("expected an array of objects")
+	}
+	values = make(map[string]interface{})
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := token.(string)
+		var value interface{}
+		if err = decoder.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+	return keys, values, nil
+}
+
+// converts the JSON, writing nothing unless it all parses. The columns are
+// the objects' keys in the order first seen; the header row is left out
+// when they are just col0, col1, ..., as csvToJSON makes them for a CSV
+// without one.
+func jsonToCSV(w io.Writer, r io.Reader) error {
+	var rows []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return err
+	}
+	keys := make([]string, 0)
+	seen := make(map[string]bool)
+	objects := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		rowKeys, values, err := orderedObject(row)
+		if err != nil {
+			return err
+		}
+		for _, key := range rowKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		objects[i] = values
+	}
+
+	csvWriter := csv.NewWriter(w)
+	defaultKeys := true
+	for i, key := range keys {
+		defaultKeys = defaultKeys && key == columnKey(i)
+	}
+	if !defaultKeys {
+		csvWriter.Write(keys)
+	}
+	for _, object := range objects {
+		// a missing key is an empty field, but a row missing the last
+		// keys is written shorter, as the CSV it came from was
+		record := make([]string, 0, len(keys))
+		fields := 0
+		for _, key := range keys {
+			value, ok := object[key]
+			if ok {
+				fields = len(record) + 1
+			}
+			switch value := value.(type) {
+			case string:
+				record = append(record, value)
+			case json.Number:
+				record = append(record, value.String())
+			case nil:
+				record = append(record, "")
+			default:
+				text, _ := json.Marshal(value)
+				record = append(record, string(text))
+			}
+		}
+		csvWriter.Write(record[:fields])
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// posts body to the convert endpoint
+func convert(from, to, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/goplot/convert?from="+from+"&to="+to, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	convertServer(rec, req)
+	return rec
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	tests := []struct {
+		csv, json string
+	}{
+		{"time,temp,label\n1,20.50,\"a, b\"\n2,1e3,x\n",
+			`[{"time":1,"temp":20.50,"label":"a, b"},{"time":2,"temp":1e3,"label":"x"}]` + "\n"},
+		{"1,2\n3,4\n", `[{"col0":1,"col1":2},{"col0":3,"col1":4}]` + "\n"},
+		{"1,2,3\n4,5\n", `[{"col0":1,"col1":2,"col2":3},{"col0":4,"col1":5}]` + "\n"},
+		{"x,y\n1,NaN\n", `[{"x":1,"y":"NaN"}]` + "\n"},
+	}
+	for _, test := range tests {
+		rec := convert("csv", "json", test.csv)
+		if rec.Code != http.StatusOK || rec.Body.String() != test.json {
+			t.Errorf("%q to JSON: got %d %s, want %s", test.csv, rec.Code, rec.Body, test.json)
+			continue
+		}
+		rec = convert("json", "csv", rec.Body.String())
+		if rec.Code != http.StatusOK || rec.Body.String() != test.csv {
+			t.Errorf("%q back to CSV: got %d %q", test.csv, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		from, to, body string
+	}{
+		{"csv", "csv", "1,2\n"},
+		{"xml", "json", "<a/>"},
+		{"csv", "json", "1,\"2\n"},
+		{"json", "csv", "[1, 2]"},
+		{"json", "csv", "{"},
+	}
+	for _, test := range tests {
+		rec := convert(test.from, test.to, test.body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s to %s of %q: got %d, want %d", test.from, test.to, test.body, rec.Code, http.StatusBadRequest)
+		}
+		if got := rec.Header().Get("Content-Type"); strings.Contains(got, "json") || strings.Contains(got, "csv") {
+			t.Errorf("%s to %s of %q: Content-Type %q on an error", test.from, test.to, test.body, got)
+		}
+	}
+}
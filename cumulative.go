@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// fits the first k points for every k from 2 on, to show how the line
+// settles as data comes in
+// POST /goplot/cumulative
+func cumulativeServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil || len(series) < 2 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonFits, err := json.Marshal(struct {
+		compute.Envelope
+		Fits []compute.CumulativeFit `json:"fits"`
+	}{compute.NewEnvelope(), compute.CumulativeFits(series)})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonFits)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestCumulative(t *testing.T) {
+	rec := postForm(cumulativeServer, "/goplot/cumulative", url.Values{"dataseries": {"0,1\n1,3\n2,5\n3,8"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var result struct{ Fits []compute.CumulativeFit }
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	fits := result.Fits
+	if len(fits) != 3 || fits[0].N != 2 || fits[2].N != 4 {
+		t.Fatalf("got %+v, want fits of 2, 3 and 4 points", fits)
+	}
+	// on the line until the last point
+	if *fits[1].Slope != 2 || *fits[1].Intercept != 1 || *fits[2].Slope == 2 {
+		t.Errorf("got slopes %v, %v", *fits[1].Slope, *fits[2].Slope)
+	}
+	for _, data := range []string{"1,2", "", "a,b\nc,d"} {
+		if rec := postForm(cumulativeServer, "/goplot/cumulative", url.Values{"dataseries": {data}}); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: got %d, want %d", data, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+)
+
+//go:embed client/dashboard.html
+var dashboardHtml string
+
+// given Config.BasePath, for the page's own requests
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(dashboardHtml))
+
+// the list of stored series, fetched from /goplot/series by the page itself
+// GET /goplot
+func dashboardServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	c.Header().Set("Content-Type", "text/html; charset=utf-8")
+	dashboardTemplate.Execute(c, struct{ BasePath string }{config.BasePath})
+}
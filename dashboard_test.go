@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDashboard(t *testing.T) {
+	rec := httptest.NewRecorder()
+	dashboardServer(rec, httptest.NewRequest("GET", "/goplot", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "series") {
+		t.Errorf("got %d, want a page about series", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+
+	// what the page lists
+	before := time.Now().Add(-time.Second)
+	storeSeries(t, "dashboarded", "1,2\n2,4\n3,6")
+	rec = httptest.NewRecorder()
+	seriesListServer(rec, httptest.NewRequest("GET", "/goplot/series", nil))
+	var listing struct{ Series []SeriesSummary }
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil {
+		t.Fatal(err)
+	}
+	for _, summary := range listing.Series {
+		if summary.Name != "dashboarded" {
+			continue
+		}
+		if summary.PointCount != 3 || summary.Slope == nil || *summary.Slope != 2 || summary.LastUpdated.Before(before) {
+			t.Errorf("got %+v, want 3 points with slope 2, just updated", summary)
+		}
+		return
+	}
+	t.Errorf("dashboarded not in %s", rec.Body)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// highest degree /goplot/debug/polynomial solves for
+const maxDebugDegree = 10
+
+// the normal equations of a polynomial fit and their solution
+type polynomialSystem struct {
+	compute.Envelope
+	Matrix       [][]float64 `json:"matrix"`
+	RHS          []float64   `json:"rhs"`
+	Coefficients []float64   `json:"coefficients"`
+}
+
+// shows the normal equations behind a polynomial fit, for debugging it.
+// Only served while Config.DebugEnabled is set.
+// POST /goplot/debug/polynomial?degree=2
+func debugPolynomialServer(c http.ResponseWriter, req *http.Request) {
+	if !config.DebugEnabled {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	degree, err := intParam(req, "degree", 2)
+	if err != nil || degree < 1 || degree > maxDebugDegree {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	if len(series) <= degree {
+		serveError(c, req, http.StatusUnprocessableEntity)
+		return
+	}
+
+	system := polynomialSystem{Envelope: compute.NewEnvelope()}
+	system.Matrix, system.RHS = compute.NormalEquations(series, degree)
+	// the solver works in place, so hand it copies
+	matrix := make([][]float64, len(system.Matrix))
+	for i, row := range system.Matrix {
+		matrix[i] = append([]float64(nil), row...)
+	}
+	if system.Coefficients, err = compute.SolveLinearSystem(matrix, append([]float64(nil), system.RHS...)); err != nil {
+		serveErrorFor(c, req, &compute.RegressionError{Model: "polynomial", Err: err})
+		return
+	}
+
+	jsonSystem, err := json.Marshal(system)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSystem)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestDebugPolynomial(t *testing.T) {
+	// y = x² + 1 at x = 0..3
+	form := url.Values{"dataseries": {"0,1\n1,2\n2,5\n3,10"}, "degree": {"2"}}
+	if rec := postForm(debugPolynomialServer, "/goplot/debug/polynomial", form); rec.Code != http.StatusNotFound {
+		t.Errorf("without DebugEnabled: got %d, want 404", rec.Code)
+	}
+
+	// the verbose logging of Debug isn't needed
+	config.DebugEnabled = true
+	defer func() { config.DebugEnabled = false }()
+	rec := postForm(debugPolynomialServer, "/goplot/debug/polynomial", form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var system polynomialSystem
+	if err := json.Unmarshal(rec.Body.Bytes(), &system); err != nil {
+		t.Fatal(err)
+	}
+	// sums of x⁰..x⁴, and of y·x⁰..y·x²
+	wantMatrix := [][]float64{{4, 6, 14}, {6, 14, 36}, {14, 36, 98}}
+	wantRHS := []float64{18, 42, 112}
+	if !reflect.DeepEqual(system.Matrix, wantMatrix) || !reflect.DeepEqual(system.RHS, wantRHS) {
+		t.Errorf("got %v = %v, want %v = %v", system.Matrix, system.RHS, wantMatrix, wantRHS)
+	}
+	for i, want := range []float64{1, 0, 1} {
+		if math.Abs(system.Coefficients[i]-want) > 1e-9 {
+			t.Errorf("coefficients %v, want [1 0 1]", system.Coefficients)
+			break
+		}
+	}
+}
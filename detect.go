@@ -0,0 +1,29 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// guesses the delimiter, decimal separator and layout of pasted data
+// POST /goplot/detect-format with dataseries
+func detectFormatServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	src := req.FormValue("dataseries")
+	if src == "" {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonFormat, err := json.Marshal(compute.DetectFormat(src))
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonFormat)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goplot/compute"
+	"net/http"
+	"os"
+)
+
+// a config setting that is missing or wrong
+type ConfigError struct {
+	Field string // the Config field, "" when the file as a whole is bad
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// a named series that couldn't be read or written
+type StorageError struct {
+	Series string
+	Err    error
+}
+
+func (e *StorageError) Error() string {
+	return "series " + e.Series + ": " + e.Err.Error()
+}
+
+func (e *StorageError) Unwrap() error { return e.Err }
+
+// the status code and message reporting err to the client. Only errors
+// about the request are described; the rest are logged and hidden.
+func errorToHTTP(err error) (int, string) {
+	var parseError *compute.ParseError
+	var regressionError *compute.RegressionError
+	var storageError *StorageError
+	switch {
+	case errors.As(err, &parseError):
+		return http.StatusBadRequest, parseError.Error()
+	case errors.As(err, &regressionError):
+		return http.StatusUnprocessableEntity, regressionError.Error()
+	case errors.As(err, &storageError):
+		return http.StatusServiceUnavailable, "series storage unavailable"
+	case errors.Is(err, errSessionFull):
+		return http.StatusRequestEntityTooLarge, errSessionFull.Error()
+	case errors.Is(err, errSharedFull):
+		return http.StatusRequestEntityTooLarge, errSharedFull.Error()
+	case errors.Is(err, errUploadTooLarge):
+		return http.StatusRequestEntityTooLarge, errUploadTooLarge.Error()
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, "processing took too long"
+	}
+	return http.StatusInternalServerError, "internal error"
+}
+
+// reports err with the status code errorToHTTP picks for it
+func serveErrorFor(c http.ResponseWriter, req *http.Request, err error) {
+	code, message := errorToHTTP(err)
+	if code >= 500 {
+		fmt.Fprintf(os.Stderr, "%s %s: %s\n", req.Method, req.URL.Path, err.Error())
+	}
+	c.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	c.WriteHeader(code)
+	fmt.Fprintln(c, message)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goplot/compute"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrorToHTTP(t *testing.T) {
+	parseError := &compute.ParseError{Line: 3, Err: errors.New("expected at least 2 fields")}
+	tests := []struct {
+		err         error
+		wantCode    int
+		wantMessage string
+	}{
+		{parseError, http.StatusBadRequest, "line 3: expected at least 2 fields"},
+		// found through wrapping
+		{fmt.Errorf("reading: %w", parseError), http.StatusBadRequest, "line 3: expected at least 2 fields"},
+		{&compute.RegressionError{Model: "exponential", Err: errors.New("y must be positive")}, http.StatusUnprocessableEntity, "exponential: y must be positive"},
+		{&StorageError{Series: "temps", Err: os.ErrPermission}, http.StatusServiceUnavailable, "series storage unavailable"},
+		{errSessionFull, http.StatusRequestEntityTooLarge, errSessionFull.Error()},
+		{errUploadTooLarge, http.StatusRequestEntityTooLarge, errUploadTooLarge.Error()},
+		{fmt.Errorf("fitting: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, "processing took too long"},
+		// nothing about the request, so the details stay in the log
+		{&ConfigError{Field: "DataDir", Err: os.ErrNotExist}, http.StatusInternalServerError, "internal error"},
+		{errors.New("disk on fire"), http.StatusInternalServerError, "internal error"},
+	}
+	for _, test := range tests {
+		code, message := errorToHTTP(test.err)
+		if code != test.wantCode || message != test.wantMessage {
+			t.Errorf("%v: got %d %q, want %d %q", test.err, code, message, test.wantCode, test.wantMessage)
+		}
+	}
+}
+
+func TestServeErrorFor(t *testing.T) {
+	rec := httptest.NewRecorder()
+	serveErrorFor(rec, httptest.NewRequest("POST", "/goplot/viz", nil), &compute.ParseError{Line: 2, Err: errors.New("bad")})
+	if rec.Code != http.StatusBadRequest || strings.TrimSpace(rec.Body.String()) != "line 2: bad" {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// the parts of the Jupyter notebook format (v4) we generate
+type notebook struct {
+	Cells         []notebookCell         `json:"cells"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	NBFormat      int                    `json:"nbformat"`
+	NBFormatMinor int                    `json:"nbformat_minor"`
+}
+
+type notebookCell struct {
+	CellType       string                 `json:"cell_type"`
+	ExecutionCount *int                   `json:"execution_count"`
+	Metadata       map[string]interface{} `json:"metadata"`
+	Outputs        []interface{}          `json:"outputs"`
+	Source         []string               `json:"source"`
+}
+
+// exports a stored series for analysis elsewhere
+// GET /goplot/export?format=notebook&series=name
+func exportServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	name := req.FormValue("series")
+	if validSeriesName(name) != nil || req.FormValue("format") != "notebook" {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if !seriesAllowed(req.Header.Get("X-API-Key"), name) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	lock := seriesLocks.Lock(name)
+	lock.RLock()
+	defer lock.RUnlock()
+	if !seriesExists(name) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonNotebook, err := json.Marshal(seriesNotebook(name, stored))
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/x-ipynb+json")
+	c.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".ipynb"))
+	c.Write(jsonNotebook)
+}
+
+// a notebook that loads the series, fits it with scipy and plots the result
+func seriesNotebook(name string, stored *StoredSeries) *notebook {
+	points := make([]string, len(stored.Series))
+	for i, pt := range stored.Series {
+		points[i] = "(" + strconv.FormatFloat(pt.X, 'g', -1, 64) + ", " + strconv.FormatFloat(pt.Y, 'g', -1, 64) + ")"
+	}
+
+	return &notebook{
+		Cells: []notebookCell{
+			codeCell("import pandas as pd\n", "import matplotlib.pyplot as plt\n", "from scipy import stats"),
+			codeCell(fmt.Sprintf("# series %q exported from goplot\n", name),
+				"data = ["+strings.Join(points, ", ")+"]\n",
+				"df = pd.DataFrame(data, columns=[\"x\", \"y\"])"),
+			codeCell("fit = stats.linregress(df[\"x\"], df[\"y\"])\n", "fit"),
+			codeCell("plt.scatter(df[\"x\"], df[\"y\"], label=\"data\")\n",
+				"plt.plot(df[\"x\"], fit.slope * df[\"x\"] + fit.intercept, color=\"red\", label=\"fit\")\n",
+				"plt.legend()\n",
+				"plt.show()"),
+		},
+		Metadata: map[string]interface{}{
+			"kernelspec":    map[string]string{"display_name": "Python 3", "language": "python", "name": "python3"},
+			"language_info": map[string]string{"name": "python"},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 4,
+	}
+}
+
+func codeCell(source ...string) notebookCell {
+	return notebookCell{CellType: "code",
+		Metadata: map[string]interface{}{},
+		Outputs:  []interface{}{},
+		Source:   source}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportNotebook(t *testing.T) {
+	storeSeries(t, "notebooksrc", "1,2\n2,4\n3,7\n4,8\n5,11")
+	rec := httptest.NewRecorder()
+	exportServer(rec, httptest.NewRequest("GET", "/goplot/export?format=notebook&series=notebooksrc", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ipynb+json" {
+		t.Errorf("Content-Type %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="notebooksrc.ipynb"` {
+		t.Errorf("Content-Disposition %q", got)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if string(fields["nbformat"]) != "4" {
+		t.Errorf("nbformat %s, want 4", fields["nbformat"])
+	}
+	var nb notebook
+	if err := json.Unmarshal(rec.Body.Bytes(), &nb); err != nil {
+		t.Fatal(err)
+	}
+	points := -1
+	for _, cell := range nb.Cells {
+		for _, line := range cell.Source {
+			if strings.HasPrefix(line, "data = [") {
+				points = strings.Count(line, "(")
+			}
+		}
+	}
+	if points != 5 {
+		t.Errorf("the data cell holds %d points, want 5", points)
+	}
+
+	for target, want := range map[string]int{
+		"/goplot/export?format=notebook&series=nosuchseries": http.StatusNotFound,
+		"/goplot/export?format=pdf&series=notebooksrc":       http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		exportServer(rec, httptest.NewRequest("GET", target, nil))
+		if rec.Code != want {
+			t.Errorf("%s: got %d, want %d", target, rec.Code, want)
+		}
+	}
+}
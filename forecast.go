@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+)
+
+// predictions continuing the least squares line past the data
+type forecast struct {
+	compute.Envelope
+	Level float64 `json:"level"`
+	// the x distance between forecast points
+	Step        float64              `json:"step"`
+	Predictions []compute.Prediction `json:"predictions"`
+}
+
+var errBadSteps = errors.New("steps must be between 1 and MaxPredictions")
+
+// extends the least squares line of the posted data series steps points
+// beyond its largest x, spaced like the data, with prediction intervals
+// POST /goplot/forecast?steps=10&level=0.95
+func forecastServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	level, err := floatParam(req, "level", config.PredictionLevel)
+	if err != nil || level <= 0 || level >= 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	steps, err := intParam(req, "steps", 0)
+	if err != nil {
+		serveErrorFor(c, req, &compute.ParseError{Err: err})
+		return
+	}
+	if steps < 1 || steps > config.MaxPredictions {
+		serveErrorFor(c, req, &compute.ParseError{Err: errBadSteps})
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	result := forecast{Envelope: compute.NewEnvelope(), Level: level}
+	xs, step, err := compute.ForecastXs(series, steps)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	result.Step = step
+	if result.Predictions, err = compute.Predict(series, xs, level); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonForecast, err := json.Marshal(result)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonForecast)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestForecastContinuesLine(t *testing.T) {
+	data := "0,1.1\n1,2.9\n2,5.2\n3,6.8\n4,9.1\n6,13"
+	form := url.Values{"dataseries": {data}}
+	rec := postForm(forecastServer, "/goplot/forecast?steps=3", form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var result forecast
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Step != 1 || len(result.Predictions) != 3 {
+		t.Fatalf("got %d predictions %v apart, want 3 at 1", len(result.Predictions), result.Step)
+	}
+	line := postViz(t, form).RegressionLine
+	width := 0.0
+	for i, prediction := range result.Predictions {
+		if want := float64(7 + i); prediction.X != want {
+			t.Errorf("prediction %d at x = %v, want %v", i, prediction.X, want)
+		}
+		if want := line.Slope*prediction.X + line.Intercept; math.Abs(prediction.Y-want) > 1e-9 {
+			t.Errorf("y(%v) = %v, want %v on the fitted line", prediction.X, prediction.Y, want)
+		}
+		// wider the further out it goes
+		interval := prediction.PredictionInterval
+		if interval[0] >= prediction.Y || interval[1] <= prediction.Y || interval[1]-interval[0] <= width {
+			t.Errorf("y(%v) = %v with interval %v after a width of %v", prediction.X, prediction.Y, interval, width)
+		}
+		width = interval[1] - interval[0]
+	}
+
+	for target, want := range map[string]int{
+		"/goplot/forecast":                  http.StatusBadRequest,
+		"/goplot/forecast?steps=0":          http.StatusBadRequest,
+		"/goplot/forecast?steps=many":       http.StatusBadRequest,
+		"/goplot/forecast?steps=1000000":    http.StatusBadRequest,
+		"/goplot/forecast?steps=2&level=1":  http.StatusBadRequest,
+		"/goplot/forecast?steps=2&level=.9": http.StatusOK,
+	} {
+		if rec := postForm(forecastServer, target, form); rec.Code != want {
+			t.Errorf("%s: got %d, want %d", target, rec.Code, want)
+		}
+	}
+	if rec := postForm(forecastServer, "/goplot/forecast?steps=2", url.Values{"dataseries": {"1,1\n1,2\n1,3"}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("one x: got %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"goplot/compute"
+	"goplot/msgpack"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// response formats by media type
+var mediaTypeFormats = map[string]string{
+	"application/json":     "json",
+	"text/csv":             "csv",
+	"application/msgpack":  "msgpack",
+	"application/x-ndjson": "ndjson",
+	"text/plain":           "plain",
+	"application/xml":      "xml",
+	"text/xml":             "xml",
+}
+
+var formatContentTypes = map[string]string{
+	"json":    "application/json; charset=utf-8",
+	"csv":     "text/csv",
+	"msgpack": "application/msgpack",
+	"ndjson":  "application/x-ndjson",
+	"plain":   "text/plain; charset=utf-8",
+	"xml":     "application/xml; charset=utf-8",
+	// asked for by format=array only; Accept: application/json gets the
+	// whole sample
+	"array": "application/json; charset=utf-8",
+}
+
+// whether the format is in Config.AllowedFormats, given as allowedFormats
+func formatAllowed(format string, allowedFormats []string) bool {
+	if len(allowedFormats) == 0 {
+		return true
+	}
+	for _, allowed := range allowedFormats {
+		if allowed == format {
+			return true
+		}
+	}
+	return false
+}
+
+// picks the response format from the format form field, or else the Accept
+// header. Media types are tried in order of preference, skipping formats
+// that aren't allowed; a wildcard, or nothing we know, falls back to the
+// configured default. "" when the format field names a disallowed format.
+func negotiateFormat(req *http.Request) string {
+	if format := req.FormValue("format"); formatContentTypes[format] != "" {
+		if !formatAllowed(format, config.AllowedFormats) {
+			return ""
+		}
+		return format
+	}
+	type acceptRange struct {
+		mediaType string
+		q         float64
+	}
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(req.Header.Get("Accept"), ",") {
+		fields := strings.Split(part, ";")
+		r := acceptRange{mediaType: strings.ToLower(strings.TrimSpace(fields[0])), q: 1.0}
+		if r.mediaType == "" {
+			continue
+		}
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		if r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		if format, ok := mediaTypeFormats[r.mediaType]; ok && formatAllowed(format, config.AllowedFormats) {
+			return format
+		}
+		if strings.HasSuffix(r.mediaType, "/*") {
+			break
+		}
+	}
+	return config.DefaultResponseFormat
+}
+
+// sends the data sample in the negotiated format
+func writeDataSample(c http.ResponseWriter, req *http.Request, dataSample *compute.DataSample) {
+	switch negotiateFormat(req) {
+	case "csv":
+		// the fit as a header row and a row of values. With includeSummary
+		// the points are exported instead, followed by the fit as comment
+		// lines, which the parser skips on import.
+		summary, _ := strconv.ParseBool(req.FormValue("includeSummary"))
+		line := dataSample.RegressionLine
+		if line == nil && !summary {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		c.Header().Set("Content-Type", formatContentTypes["csv"])
+		csvWriter := csv.NewWriter(c)
+		if !summary {
+			csvWriter.Write([]string{"slope", "intercept", "stdError", "correlation", "n"})
+			csvWriter.Write([]string{strconv.FormatFloat(line.Slope, 'g', -1, 64),
+				strconv.FormatFloat(line.Intercept, 'g', -1, 64),
+				strconv.FormatFloat(line.StdError, 'g', -1, 64),
+				strconv.FormatFloat(line.Correlation, 'g', -1, 64),
+				strconv.Itoa(len(dataSample.Series))})
+			csvWriter.Flush()
+			return
+		}
+		for _, pt := range dataSample.Series {
+			csvWriter.Write([]string{strconv.FormatFloat(pt.X, 'g', -1, 64),
+				strconv.FormatFloat(pt.Y, 'g', -1, 64)})
+		}
+		csvWriter.Flush()
+		if line != nil {
+			fmt.Fprintf(c, "# slope: %s\n# intercept: %s\n# r2: %s\n# n: %d\n",
+				strconv.FormatFloat(line.Slope, 'g', -1, 64),
+				strconv.FormatFloat(line.Intercept, 'g', -1, 64),
+				strconv.FormatFloat(line.Correlation*line.Correlation, 'g', -1, 64), len(dataSample.Series))
+		}
+	case "msgpack":
+		data, err := msgpack.Marshal(dataSample)
+		if err != nil {
+			fmt.Println(err)
+			serveError(c, req, http.StatusInternalServerError)
+			return
+		}
+		c.Header().Set("Content-Type", formatContentTypes["msgpack"])
+		c.Write(data)
+	case "array":
+		// [slope, intercept, r², stdError, n] as a JSON array, in that
+		// order, for clients that would rather index than look up names
+		line := dataSample.RegressionLine
+		if line == nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		data, err := json.Marshal([]float64{line.Slope, line.Intercept,
+			line.Correlation * line.Correlation, line.StdError, float64(len(dataSample.Series))})
+		if err != nil {
+			fmt.Println(err)
+			serveError(c, req, http.StatusInternalServerError)
+			return
+		}
+		c.Header().Set("Content-Type", formatContentTypes["array"])
+		c.Write(data)
+	case "xml":
+		data, err := xml.Marshal(dataSample)
+		if err != nil {
+			fmt.Println(err)
+			serveError(c, req, http.StatusInternalServerError)
+			return
+		}
+		c.Header().Set("Content-Type", formatContentTypes["xml"])
+		io.WriteString(c, xml.Header)
+		c.Write(data)
+	case "plain":
+		// "slope intercept rsquared n" on one line, for shell scripts
+		line := dataSample.RegressionLine
+		if line == nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		c.Header().Set("Content-Type", formatContentTypes["plain"])
+		fmt.Fprintf(c, "%s %s %s %d\n", strconv.FormatFloat(line.Slope, 'g', -1, 64),
+			strconv.FormatFloat(line.Intercept, 'g', -1, 64),
+			strconv.FormatFloat(line.Correlation*line.Correlation, 'g', -1, 64), len(dataSample.Series))
+	default:
+		if err := streamDataSample(c, dataSample); err != nil {
+			fmt.Println(err)
+			serveError(c, req, http.StatusInternalServerError)
+		}
+	}
+}
+
+// writes the data sample as JSON, sending the series one point per write so
+// a large series goes out chunked rather than being built up in memory.
+// Only fails before anything has been written.
+func streamDataSample(w http.ResponseWriter, ds *compute.DataSample) error {
+	// everything but the series is encoded up front; the series is the
+	// first field, so this comes out as {"series":null,...}
+	rest := *ds
+	rest.Series = nil
+	tail, err := json.Marshal(&rest)
+	if err != nil {
+		return err
+	}
+	tail = bytes.TrimPrefix(tail, []byte(`{"series":null`))
+
+	w.Header().Set("Content-Type", formatContentTypes["json"])
+	io.WriteString(w, `{"series":[`)
+	encoder := json.NewEncoder(w)
+	for i := range ds.Series {
+		if i > 0 {
+			io.WriteString(w, ",")
+		}
+		encoder.Encode(&ds.Series[i])
+	}
+	io.WriteString(w, "]")
+	w.Write(tail)
+	return nil
+}
+
+// a line of an NDJSON response
+type ndjsonLine struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// processes the data sample and sends it as newline delimited JSON, one
+// {"type": ..., "data": ...} line per part. Each line is flushed as it is
+// written, and the points go out before any fitting starts, so the client
+// can plot them while the rest is computed.
+func streamNDJSON(c http.ResponseWriter, req *http.Request, src string, options *Options) {
+	flusher, _ := c.(http.Flusher)
+	encoder := json.NewEncoder(c)
+	started := false
+	line := func(kind string, data interface{}) {
+		if !started {
+			c.Header().Set("Content-Type", formatContentTypes["ndjson"])
+			started = true
+		}
+		if err := encoder.Encode(ndjsonLine{kind, data}); err != nil {
+			fmt.Println(err)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	options.OnParsed = func(series []compute.Point) { line("series", series) }
+	dataSample, err := dataSampleProcess(req.Context(), src, options)
+	if err != nil {
+		fmt.Println(err)
+		// too late for a status code once the points are out
+		if started {
+			_, message := errorToHTTP(err)
+			line("error", message)
+		} else {
+			serveErrorFor(c, req, err)
+		}
+		return
+	}
+	if dataSample.RegressionLine != nil {
+		line("regression", dataSample.RegressionLine)
+	}
+	line("metadata", dataSample.Metadata)
+	if dataSample.QualityGrade != "" {
+		line("quality", map[string]interface{}{"score": dataSample.QualityScore, "grade": dataSample.QualityGrade})
+	}
+	if dataSample.BootstrapSlopeInterval != nil {
+		line("bootstrap", map[string]interface{}{"slopeInterval": dataSample.BootstrapSlopeInterval,
+			"approximate": dataSample.Approximate})
+	}
+	if dataSample.GeoBounds != nil {
+		line("geoBounds", dataSample.GeoBounds)
+	}
+	if dataSample.ExcludedCount > 0 {
+		excluded := map[string]interface{}{"count": dataSample.ExcludedCount}
+		if dataSample.ExcludedPoints != nil {
+			excluded["points"] = dataSample.ExcludedPoints
+		}
+		line("excluded", excluded)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	saved := config.DefaultResponseFormat
+	defer func() { config.DefaultResponseFormat = saved }()
+	config.DefaultResponseFormat = "csv"
+
+	tests := []struct {
+		target, accept string
+		want           string
+	}{
+		{"/goplot/viz", "*/*", "csv"},
+		{"/goplot/viz", "", "csv"},
+		{"/goplot/viz", "application/x-unknown", "csv"},
+		{"/goplot/viz", "application/json", "json"},
+		{"/goplot/viz", "text/csv;q=0.5, application/json", "json"},
+		{"/goplot/viz", "application/x-unknown, application/json;q=0.2", "json"},
+		// a wildcard ahead of a known type stops the search
+		{"/goplot/viz", "*/*, application/json;q=0.5", "csv"},
+		{"/goplot/viz?format=json", "text/csv", "json"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", test.target, nil)
+		req.Header.Set("Accept", test.accept)
+		if got := negotiateFormat(req); got != test.want {
+			t.Errorf("%s with Accept %q: got %q, want %q", test.target, test.accept, got, test.want)
+		}
+	}
+}
+
+func TestStreamDataSample(t *testing.T) {
+	for _, n := range []int{3, 1000} {
+		series := make([]compute.Point, n)
+		for i := range series {
+			series[i] = compute.Point{X: float64(i), Y: 2*float64(i) + 1}
+		}
+		ds := compute.NewDataSample(series, compute.Metadata{XLabel: "x"})
+		rec := httptest.NewRecorder()
+		if err := streamDataSample(rec, ds); err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(rec.Body.Bytes()) {
+			t.Fatalf("%d points: not JSON: %.200s", n, rec.Body)
+		}
+		var streamed compute.DataSample
+		if err := json.Unmarshal(rec.Body.Bytes(), &streamed); err != nil {
+			t.Fatal(err)
+		}
+		if len(streamed.Series) != n || !reflect.DeepEqual(streamed.Metadata, ds.Metadata) ||
+			streamed.RegressionLine.Slope != ds.RegressionLine.Slope {
+			t.Errorf("%d points: streamed %d points, metadata %+v, line %+v", n, len(streamed.Series), streamed.Metadata, streamed.RegressionLine)
+		}
+	}
+
+	// a line that can't be encoded fails before anything is written
+	rec := httptest.NewRecorder()
+	if err := streamDataSample(rec, compute.NewDataSample(nil, compute.Metadata{})); err == nil || rec.Body.Len() > 0 {
+		t.Errorf("got %v after writing %q, want an error before writing", err, rec.Body)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7\n4,8"}, "xlabel": {"t"}}
+	req := httptest.NewRequest("POST", "/goplot/viz", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	dataSampleServer(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("got %d %s: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+	}
+	if !rec.Flushed {
+		t.Error("not flushed")
+	}
+
+	var types []string
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var line struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("%q: %v", scanner.Text(), err)
+		}
+		types = append(types, line.Type)
+		if line.Type == "series" {
+			var series []compute.Point
+			if err := json.Unmarshal(line.Data, &series); err != nil || len(series) != 4 {
+				t.Errorf("series line %s: %v", line.Data, err)
+			}
+		}
+	}
+	// the points come before anything fitted from them
+	if len(types) < 3 || types[0] != "series" || types[1] != "regression" || types[2] != "metadata" {
+		t.Errorf("got lines of type %v, want series, regression, metadata first", types)
+	}
+}
+
+func TestPlainFormat(t *testing.T) {
+	rec := postForm(dataSampleServer, "/goplot/viz?format=plain", url.Values{"dataseries": {"0,1\n1,3\n2,5\n3,7"}})
+	if rec.Code != http.StatusOK || rec.Body.String() != "2 1 1 4\n" {
+		t.Errorf("got %d %q, want \"2 1 1 4\\n\"", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type %q", got)
+	}
+	// there is nothing to print without a line
+	rec = postForm(dataSampleServer, "/goplot/viz?format=plain", url.Values{"dataseries": {"0,1\n1,3"}, "regression": {"0"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without regression: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestArrayFormat(t *testing.T) {
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7\n4,8"}}
+	rec := postForm(dataSampleServer, "/goplot/viz?format=array", form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var got []float64
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	// the same fit as the named fields
+	line := postViz(t, form).RegressionLine
+	want := []float64{line.Slope, line.Intercept, line.Correlation * line.Correlation, line.StdError, 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if math.Abs(got[0]-2.1) > 1e-9 || math.Abs(got[1]) > 1e-9 || math.Abs(got[2]-110.25/113.75) > 1e-9 {
+		t.Errorf("got %v, want slope 2.1, intercept 0 and r² 0.969", got)
+	}
+	rec = postForm(dataSampleServer, "/goplot/viz?format=array", url.Values{"dataseries": {"0,1\n1,3"}, "regression": {"0"}})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("without regression: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestAllowedFormats(t *testing.T) {
+	saved := config.AllowedFormats
+	defer func() { config.AllowedFormats = saved }()
+	config.AllowedFormats = []string{"json", "csv"}
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7"}}
+
+	if rec := postForm(dataSampleServer, "/goplot/viz?format=msgpack", form); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("format=msgpack: got %d, want %d", rec.Code, http.StatusNotAcceptable)
+	}
+	req := httptest.NewRequest("POST", "/goplot/viz", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/msgpack, application/json;q=0.5")
+	rec := httptest.NewRecorder()
+	dataSampleServer(rec, req)
+	if got := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || got != formatContentTypes["json"] {
+		t.Errorf("msgpack preferred: got %d %q, want the allowed JSON", rec.Code, got)
+	}
+}
+
+func TestCSVSummaryRoundTrip(t *testing.T) {
+	data := "0,1\n1,3\n2,5\n3,7.5"
+	rec := postForm(dataSampleServer, "/goplot/viz?format=csv&includeSummary=1", url.Values{"dataseries": {data}})
+	exported := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(exported, "\n# slope: ") || !strings.HasSuffix(exported, "# n: 4\n") {
+		t.Fatalf("got %d %q, want the points and a summary", rec.Code, exported)
+	}
+
+	parsed, err := compute.ParseColumns(exported, compute.ParseOptions{Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, _ := compute.ParseSeries(data)
+	if !reflect.DeepEqual(parsed.Series, original) || parsed.SkippedLines != 0 {
+		t.Errorf("read back %v with %d skipped lines, want %v", parsed.Series, parsed.SkippedLines, original)
+	}
+	if rec := postForm(dataSampleServer, "/goplot/viz?format=csv", url.Values{"dataseries": {data}}); strings.Contains(rec.Body.String(), "#") {
+		t.Errorf("summary without includeSummary: %q", rec.Body)
+	}
+	// the points alone, when there is no fit
+	rec = postForm(dataSampleServer, "/goplot/viz?format=csv&includeSummary=1", url.Values{"dataseries": {data}, "regression": {"0"}})
+	if rec.Code != http.StatusOK || rec.Body.String() != "0,1\n1,3\n2,5\n3,7.5\n" {
+		t.Errorf("without regression: got %d %q", rec.Code, rec.Body)
+	}
+	if rec := postForm(dataSampleServer, "/goplot/viz?format=csv", url.Values{"dataseries": {data}, "regression": {"0"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("stats without regression: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestContentTypePerFormat(t *testing.T) {
+	form := url.Values{"dataseries": {"0,1\n1,3\n2,5\n3,7"}}
+	tests := []struct {
+		accept, wantType string
+		check            func(body []byte) error
+	}{
+		{"application/json", "application/json; charset=utf-8", func(body []byte) error {
+			var dataSample compute.DataSample
+			return json.Unmarshal(body, &dataSample)
+		}},
+		{"application/xml", "application/xml; charset=utf-8", func(body []byte) error {
+			var dataSample compute.DataSample
+			if err := xml.Unmarshal(body, &dataSample); err != nil {
+				return err
+			}
+			if len(dataSample.Series) != 4 || dataSample.RegressionLine == nil || dataSample.RegressionLine.Slope != 2 {
+				return fmt.Errorf("decoded %d points and line %v", len(dataSample.Series), dataSample.RegressionLine)
+			}
+			return nil
+		}},
+		{"text/csv", "text/csv", func(body []byte) error {
+			records, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+			want := [][]string{{"slope", "intercept", "stdError", "correlation", "n"}, {"2", "1", "0", "1", "4"}}
+			if err == nil && !reflect.DeepEqual(records, want) {
+				err = fmt.Errorf("read %v, want %v", records, want)
+			}
+			return err
+		}},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/goplot/viz", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", test.accept)
+		rec := httptest.NewRecorder()
+		dataSampleServer(rec, req)
+		if got := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || got != test.wantType {
+			t.Errorf("Accept %s: got %d %q, want %q", test.accept, rec.Code, got, test.wantType)
+			continue
+		}
+		if err := test.check(rec.Body.Bytes()); err != nil {
+			t.Errorf("Accept %s: %v", test.accept, err)
+		}
+	}
+}
@@ -1,54 +1,149 @@
 package main
 
 import (
-	"encoding/csv"
+	"context"
+	_ "embed"
 	"encoding/json"
+	"errors"
 	"expvar"
 	"flag"
 	"fmt"
+	"goplot/compute"
 	. "goplot/constants"
-	_ "goplot/httplog"
-	"io"
-	"io/ioutil"
+	"goplot/httplog"
+	"log/slog"
 	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// the demo point served at /point and published through expvar
 type Point struct {
-	X float64 `json:"x"`
-	Y float64 `json:"y"`
-}
-
-type RegressionLine struct {
-	Slope       float64 `json:"slope"`
-	Intercept   float64 `json:"intercept"`
-	StdError    float64 `json:"stdError"`
-	Correlation float64 `json:"correlation"`
-}
-
-type DataSample struct {
-	Series         []Point        `json:"series"`
-	RegressionLine RegressionLine `json:"regressionLine"`
+	mu sync.Mutex
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
 }
 
 type Config struct {
-	Address   string
+	Address   string // host:port, or unix:/path/to.sock
 	CustomLog string
 	LogFormat []string
+	DataDir   string // where named series are stored
+	// upper bound on the bootstrap form field
+	MaxBootstrap int
+	// cells along each side of a render=density grid, unless the request
+	// gives a gridSize
+	DensityGridSize int
+	// fraction of the data range added on each side of the axis bounds,
+	// unless the request gives a pad
+	AxisPadding float64
+	// how long a /goplot/viz?session=true session is kept unused, e.g. "1h"
+	SessionTTL string
+	// lines a /goplot/viz input may have, and the bound on its maxLines field
+	MaxPoints int
+	// "json" or "csv", used when the Accept header doesn't settle it
+	DefaultResponseFormat string
+	// the response formats served, all of them while empty
+	AllowedFormats []string
+	// API key -> series name prefixes it may use, "*" for all series.
+	// Named series are open to everyone while this is empty.
+	SeriesACL map[string][]string
+	// terms and grade boundaries of the quality score
+	QualityWeights compute.QualityWeights
+	// fraction of successful requests written to the access log (0.0-1.0);
+	// errors are always logged
+	LogSampleRate float64
+	// cap on the reweighting passes of method=lad
+	MaxLADIterations int
+	// add the provenance of the request to data samples and stored series
+	TrackProvenance bool
+	// responses are cut off after this many bytes, 0 for no limit
+	MaxResponseBytes int64
+	// request body bytes a client IP may send per minute, 0 for no limit
+	MaxBytesPerMinutePerIP int64
+	// significant digits in the regression equation shown to users
+	EquationPrecision int
+	// log the parsed points and regression sums of each /goplot/viz request
+	Debug bool
+	// serve /goplot/debug/, apart from Debug so the matrices can be shown
+	// without the logging
+	DebugEnabled bool
+	// X-Admin-Secret for the /admin/ endpoints, which are off while empty
+	AdminSecret string
+	// path prefix of every route, e.g. "/monitoring" when a reverse proxy
+	// passes on /monitoring/goplot/viz; "" to serve from the root
+	BasePath string
+	// named series must match SeriesNamePattern and be at most
+	// SeriesNameMaxLen bytes long
+	SeriesNameMaxLen  int
+	SeriesNamePattern string
+	// level of the /goplot/predict intervals when the request doesn't give one
+	PredictionLevel float64
+	// how many of the latest requests /admin/analytics looks at
+	AnalyticsBufferSize int
+	// minutes without an update after which a stored series is stale
+	StaleAfterMinutes int
+	// most x values a /goplot/predict request may ask for
+	MaxPredictions int
+	// the access log is written out when this many bytes are buffered, or
+	// every LogFlushMs milliseconds
+	LogBufferSize int
+	LogFlushMs    int
+	// when set, "x,y" lines sent over UDP to this host:port are appended to
+	// the stored series UDPSeries, written out every UDPFlushIntervalMs
+	// milliseconds
+	UDPAddr            string
+	UDPSeries          string
+	UDPFlushIntervalMs int
+	// a JSON config fetched at startup whose settings are defaults for the
+	// config file's; also GOPLOT_REMOTE_CONFIG_URL
+	RemoteConfigURL string
 }
 
-func (pt *Point) String() string { return fmt.Sprintf("(%f,%f)", pt.X, pt.Y) }
+// the point as JSON, as expvar.Var requires; /debug/vars calls this
+// concurrently with ServeHTTP
+func (pt *Point) String() string {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	data, err := json.Marshal(pt)
+	if err != nil {
+		// NaN or Inf posted to /point
+		return "null"
+	}
+	return string(data)
+}
 
 func (pt *Point) ServeHTTP(c http.ResponseWriter, req *http.Request) {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
 	switch req.Method {
 	case "GET":
 		pt.X++
 	case "POST":
-		pt.X, _ = strconv.ParseFloat(req.FormValue("x"), 64)
-		pt.Y, _ = strconv.ParseFloat(req.FormValue("y"), 64)
+		x, errX := strconv.ParseFloat(req.FormValue("x"), 64)
+		y, errY := strconv.ParseFloat(req.FormValue("y"), 64)
+		if errX != nil || errY != nil {
+			invalid := make([]string, 0, 2)
+			if errX != nil {
+				invalid = append(invalid, "x")
+			}
+			if errY != nil {
+				invalid = append(invalid, "y")
+			}
+			c.Header().Set("Content-Type", "application/json")
+			c.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(c).Encode(map[string]interface{}{
+				"error":  "not a number: " + strings.Join(invalid, ", "),
+				"fields": invalid})
+			return
+		}
+		pt.X, pt.Y = x, y
 	}
 	fmt.Fprintf(c, "point is (%f,%f)\n", pt.X, pt.Y)
 }
@@ -56,58 +151,180 @@ func (pt *Point) ServeHTTP(c http.ResponseWriter, req *http.Request) {
 var configFlag = flag.String("c", "server.conf", "Config file name")
 var helpFlag = flag.Bool("h", false, "This help")
 
-// next variables are also available in server config file
-var addressFlag = flag.String("l", "0.0.0.0:6060", "Address and port to listen on (ex. 127.0.0.1:1234")
+// next variables are also available in server config file, flags win
+const addressFlagDefault = "0.0.0.0:6060"
+
+var addressFlag = flag.String("l", addressFlagDefault, "Address and port to listen on (ex. 127.0.0.1:1234")
+
+// the effective server configuration, set up by main
+var config Config
+
+// number of regressions computed for /goplot/viz, published at /debug/vars
+var regressionCount = expvar.NewInt("regressions")
 
 func main() {
-	// todo: config file overrides command line flags, this feels incorrect
 	flag.Parse()
 
 	if *helpFlag {
 		flag.PrintDefaults()
+		fmt.Println("  init\n    \twrite a config file at -c, asking for the basic settings")
 		os.Exit(EXIT_SUCCESS)
 	}
 
-	configJsonBytes, err := ioutil.ReadFile(*configFlag)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to read %s: %s\n", *configFlag, err.Error())
-		os.Exit(EXIT_NO_CONFIG)
+	if flag.Arg(0) == "init" {
+		if err := runInit(*configFlag, os.Stdin, os.Stdout, stdinIsTerminal()); err != nil {
+			fmt.Fprintf(os.Stderr, "init: %s\n", err.Error())
+			os.Exit(EXIT_INIT_FAILED)
+		}
+		os.Exit(EXIT_SUCCESS)
 	}
 
-	var config = Config{*addressFlag, "nolog", nil}
-	err = json.Unmarshal(configJsonBytes, &config)
-	if err != nil {
+	if err := selfTest(); err != nil {
+		fmt.Fprintf(os.Stderr, "self-test failed: %s\n", err.Error())
+		os.Exit(EXIT_SELF_TEST_FAILED)
+	}
+
+	var err error
+	config, err = LoadConfig(*configFlag)
+	if _, ok := err.(*os.PathError); ok {
+		fmt.Fprintf(os.Stderr, "failed to read %s: %s\n", *configFlag, err.Error())
+		os.Exit(EXIT_NO_CONFIG)
+	} else if err != nil {
 		fmt.Fprintf(os.Stderr, "Config error at %s (while reading %s)\n", strconv.Quote(err.Error()), *configFlag)
 		os.Exit(EXIT_CONFIG_PARSE)
 	}
 
-	fmt.Printf("%s\n", config.Address)
-	fmt.Printf("%s\n", config.CustomLog)
+	fmt.Print(&config)
+	if err = checkDataDirWritable(); err != nil {
+		fmt.Fprintf(os.Stderr, "DataDir %s unusable: %s\n", config.DataDir, err.Error())
+		os.Exit(EXIT_DATA_DIR_ERROR)
+	}
+	seriesLocks = NewSeriesLockManager()
+	compute.EquationPrecision = config.EquationPrecision
+	seriesNamePattern = regexp.MustCompile(config.SeriesNamePattern)
+	sessionTTL, _ := time.ParseDuration(config.SessionTTL)
+	sessions = NewSessionStore(sessionTTL)
+	analytics = NewRequestAnalytics(config.AnalyticsBufferSize)
+	go sessions.EvictEvery(time.Minute)
 
 	demoPoint := &Point{X: 0.0, Y: 0.0}
 
-	http.Handle("/point", demoPoint)
+	handle("/point", demoPoint)
 	expvar.Publish("point", demoPoint)
 
-	http.Handle("/goplot/viz", http.HandlerFunc(dataSampleServer))
+	handle("/healthz", http.HandlerFunc(healthzServer))
+	handle("/readyz", http.HandlerFunc(readyzServer))
+
+	handle("/goplot", http.HandlerFunc(dashboardServer))
+	handle("/goplot/viz", http.HandlerFunc(dataSampleServer))
+	handle("/goplot/stft", http.HandlerFunc(stftServer))
+	handle("/goplot/auto", http.HandlerFunc(autoServer))
+	handle("/goplot/series", http.HandlerFunc(seriesListServer))
+	handle("/goplot/series/", http.HandlerFunc(seriesServer))
+	handle("/goplot/intersect", http.HandlerFunc(intersectServer))
+	handle("/goplot/bland-altman", http.HandlerFunc(blandAltmanServer))
+	handle("/goplot/granger", http.HandlerFunc(grangerServer))
+	handle("/goplot/detect-format", http.HandlerFunc(detectFormatServer))
+	handle("/goplot/thin", http.HandlerFunc(thinServer))
+	handle("/goplot/models", http.HandlerFunc(modelsServer))
+	handle("/goplot/preview", http.HandlerFunc(previewServer))
+	handle("/goplot/predict", http.HandlerFunc(predictServer))
+	handle("/goplot/forecast", http.HandlerFunc(forecastServer))
+	handle("/goplot/trend", http.HandlerFunc(trendServer))
+	handle("/goplot/debug/polynomial", http.HandlerFunc(debugPolynomialServer))
+	handle("/goplot/power", http.HandlerFunc(powerServer))
+	handle("/goplot/envelope", http.HandlerFunc(envelopeServer))
+	handle("/goplot/interpolate", http.HandlerFunc(interpolateServer))
+	handle("/goplot/export", http.HandlerFunc(exportServer))
+	handle("/goplot/shared", http.HandlerFunc(sharedServer))
+	handle("/goplot/inject-anomaly", http.HandlerFunc(injectAnomalyServer))
+	handle("/goplot/from-sums", http.HandlerFunc(fromSumsServer))
+	handle("/goplot/cumulative", http.HandlerFunc(cumulativeServer))
+	handle("/goplot/pipe", http.HandlerFunc(pipeServer))
+	handle("/goplot/live", http.HandlerFunc(liveServer))
+	handle("/goplot/partition", http.HandlerFunc(partitionServer))
+	handle("/goplot/compare-table", http.HandlerFunc(compareTableServer))
+	handle("/goplot/convert", http.HandlerFunc(convertServer))
 	// serve our own files instead of using http.FileServer for very tight access control
-	http.Handle("/goplot/graph.js", http.HandlerFunc(fileServe))
+	handle("/goplot/graph.js", fileServe("graph.js"))
+	// built separately, see goplot/wasm
+	handle("/goplot/goplot.wasm", fileServe("goplot.wasm"))
+	handle("/goplot/wasm_exec.js", fileServe("wasm_exec.js"))
+	handle("/admin/reset-metrics", http.HandlerFunc(resetMetricsServer))
+	handle("/admin/analytics", http.HandlerFunc(analyticsServer))
+
+	if config.BasePath != "" {
+		// expvar registers itself at /debug/vars
+		handle("/debug/vars", expvar.Handler())
+	}
+
+	handler := recordLatency(http.DefaultServeMux)
+	if config.MaxResponseBytes > 0 {
+		handler = limitResponse(handler, config.MaxResponseBytes)
+	}
+	if config.MaxBytesPerMinutePerIP > 0 {
+		handler = limitVolume(handler, NewVolumeLimiter(config.MaxBytesPerMinutePerIP))
+	}
+	var logger *httplog.Logger
+	if config.CustomLog != "nolog" {
+		flushInterval := time.Duration(config.LogFlushMs) * time.Millisecond
+		if logger, err = httplog.NewBuffered(config.CustomLog, config.LogBufferSize, flushInterval); err != nil {
+			fmt.Fprintf(os.Stderr, "access log disabled, failed to open %s: %s\n", config.CustomLog, err.Error())
+		} else {
+			handler = accessLog(handler, logger, config.LogFormat, newLogSampler(config.LogSampleRate, time.Now().UnixNano()))
+		}
+	}
+
 	// in order
-	err = http.ListenAndServe(config.Address, nil)
+	listener, err := listen(config.Address)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "ListenAndServe on %s got: %s\n", config.Address, err.Error())
+		fmt.Fprintf(os.Stderr, "Listen on %s got: %s\n", config.Address, err.Error())
+		os.Exit(EXIT_CANT_LISTEN)
+	}
+	lifecycle := lifecycleLog{logger, config.LogFormat}
+	lifecycle.printf("listening on %s", config.Address)
+	var udp *UDPIngest
+	if config.UDPAddr != "" {
+		if udp, err = ListenUDP(config.UDPAddr, config.UDPSeries, time.Duration(config.UDPFlushIntervalMs)*time.Millisecond); err != nil {
+			fmt.Fprintf(os.Stderr, "Listen on UDP %s got: %s\n", config.UDPAddr, err.Error())
+			os.Exit(EXIT_CANT_LISTEN)
+		}
+		lifecycle.printf("taking points for %s over UDP on %s", config.UDPSeries, config.UDPAddr)
+	}
+	server := &http.Server{Handler: countInFlight(handler)}
+	// live streams would otherwise hold up shutdown until its timeout
+	server.RegisterOnShutdown(live.Close)
+	stopped := shutdownOnSignal(server, lifecycle)
+	err = server.Serve(listener)
+	if err != http.ErrServerClosed {
+		fmt.Fprintf(os.Stderr, "Serve on %s got: %s\n", config.Address, err.Error())
 		os.Exit(EXIT_CANT_LISTEN)
 	}
+	<-stopped
+	if udp != nil {
+		udp.Close()
+	}
+	lifecycle.printf("stopped")
+	if logger != nil {
+		logger.Close()
+	}
+}
+
+// registers a route under Config.BasePath
+func handle(pattern string, handler http.Handler) {
+	http.Handle(config.BasePath+pattern, handler)
 }
 
 // serve static files as appropriate
-func fileServe(c http.ResponseWriter, req *http.Request) {
-	cwd, err := os.Getwd()
-	if err == nil {
-		http.ServeFile(c, req, cwd+"/client/graph.js")
-	} else {
-		serveError(c, req, http.StatusInternalServerError) // 500
-	}
+func fileServe(name string) http.Handler {
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		cwd, err := os.Getwd()
+		if err == nil {
+			http.ServeFile(c, req, cwd+"/client/"+name)
+		} else {
+			serveError(c, req, http.StatusInternalServerError) // 500
+		}
+	})
 }
 
 // Send the given error code.
@@ -115,6 +332,56 @@ func serveError(c http.ResponseWriter, req *http.Request, code int) {
 	c.WriteHeader(code)
 }
 
+// known dataset and the slope and intercept it must fit to
+//
+//go:embed testdata/selftest.json
+var selfTestJson []byte
+
+// checks LinearRegression against testdata/selftest.json, so a broken
+// regression stops the server instead of serving wrong fits
+func selfTest() error {
+	var expected struct {
+		Series    []compute.Point
+		Slope     float64
+		Intercept float64
+	}
+	if err := json.Unmarshal(selfTestJson, &expected); err != nil {
+		return err
+	}
+	slope, intercept, _, _ := compute.LinearRegression(expected.Series)
+	if !withinEpsilon(slope, expected.Slope) {
+		return fmt.Errorf("slope %v, expected %v", slope, expected.Slope)
+	}
+	if !withinEpsilon(intercept, expected.Intercept) {
+		return fmt.Errorf("intercept %v, expected %v", intercept, expected.Intercept)
+	}
+	return nil
+}
+
+// equal up to a few units of rounding relative to the expected value
+func withinEpsilon(got, expected float64) bool {
+	const epsilon = 0x1p-52
+	return math.Abs(got-expected) <= 4*epsilon*math.Max(1, math.Abs(expected))
+}
+
+// reads an optional integer query or form parameter
+func intParam(req *http.Request, name string, def int) (int, error) {
+	s := req.FormValue(name)
+	if s == "" {
+		return def, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// reads an optional float query or form parameter
+func floatParam(req *http.Request, name string, def float64) (float64, error) {
+	s := req.FormValue(name)
+	if s == "" {
+		return def, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
 // processes data samples, sends back data to plot along with regression lines
 func dataSampleServer(c http.ResponseWriter, req *http.Request) {
 	switch req.Method {
@@ -126,84 +393,424 @@ func dataSampleServer(c http.ResponseWriter, req *http.Request) {
 			serveError(c, req, http.StatusInternalServerError) // 500
 		}
 	case "POST":
-		src := req.FormValue("dataseries")
-		result := dataSampleProcess(src)
+		provenance := startProvenance(req)
+		src, err := dataSeriesFromRequest(req)
+		if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		options, err := optionsFromRequest(req)
+		if err != nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		format := negotiateFormat(req)
+		if format == "" {
+			serveError(c, req, http.StatusNotAcceptable)
+			return
+		}
+		if session, _ := strconv.ParseBool(req.FormValue("session")); session {
+			id, err := sessionID(c, req)
+			if err != nil {
+				serveErrorFor(c, req, err)
+				return
+			}
+			options.Accumulate = func(series []compute.Point) ([]compute.Point, error) {
+				return sessions.Append(id, series)
+			}
+		}
+		if format == "ndjson" {
+			streamNDJSON(c, req, src, options)
+			return
+		}
+		timeout, err := requestTimeout(req)
+		if err != nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		dataSample, err := dataSampleProcessWithin(req.Context(), timeout, src, options)
+		if errors.Is(err, context.Canceled) {
+			// the client is gone, there is no one to answer
+			return
+		} else if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		dataSample.Provenance = provenance.finish(req)
 		// send the response
-		fmt.Fprint(c, result)
+		writeDataSample(c, req, dataSample)
 	default:
 		serveError(c, req, http.StatusMethodNotAllowed)
 	}
 }
 
-// processes data samples, sends back data to plot along with regression lines
-func dataSampleProcess(src string) (results string) {
-	const MAXLINES = 1000000
-	series := make([]Point, 0)
-
-	csvReader := csv.NewReader(strings.NewReader(src))
-	for i := 1; i < MAXLINES; i++ {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			fmt.Println(err)
-			return
+// axis labels and units from the request form
+func metadataFromRequest(req *http.Request) compute.Metadata {
+	return compute.Metadata{XLabel: req.FormValue("xlabel"),
+		YLabel: req.FormValue("ylabel"),
+		XUnit:  req.FormValue("xunit"),
+		YUnit:  req.FormValue("yunit")}
+}
+
+var errUnknownCRS = errors.New("unsupported inputCRS, only WGS84 is known")
+var errBadTimeout = errors.New("X-Request-Timeout must be positive")
+var errBadTrim = errors.New("trim must be between 0 and 0.2")
+var errUnknownMethod = errors.New("unknown regression method, see /goplot/models")
+var errBadTicks = errors.New("ticks must be between 0 and 50")
+var errBadFitSpace = errors.New("fitSpace must be linear, or loglog with method=ols")
+var errBadRegressionType = errors.New("regressionType must be auto, with method=ols and a linear fitSpace")
+var errUnknownRender = errors.New("render must be points or density")
+var errBadGridSize = errors.New("gridSize must be between 1 and 500")
+var errUnknownDiagnostics = errors.New("diagnostics must be influence")
+var errBadPad = errors.New("pad must be between 0 and 1")
+
+// upper bound on the gridSize form field
+const maxGridSize = 500
+
+// upper bound on the ticks form field
+const maxTicks = 50
+
+// per-request processing options, from the form fields
+type Options struct {
+	Metadata  compute.Metadata
+	Bootstrap int // number of bootstrap resamples, 0 for none
+	Seed      int64
+	InputCRS  string // "WGS84" when x is longitude and y latitude
+	// regression=0 only parses the points, for plotting
+	Regression bool
+	// the fit model from fitModels, "ols" for least squares by default
+	Method string
+	// "loglog" fits ols on log-transformed axes, giving a power law
+	FitSpace string
+	// "auto" picks a linear, logarithmic, exponential or power law model
+	// by the shape of the data
+	RegressionType string
+	// the request form, passed on to the fit model
+	Params url.Values
+	// fraction of the largest residuals to drop before refitting, 0-0.2
+	Trim  float64
+	Parse compute.ParseOptions
+	// how long expensive computations may run, 0 for no limit
+	TimeBudget time.Duration
+	// inclusive X range of the points to fit, infinite when not set
+	XMin, XMax float64
+	// return the points outside the range too, for display
+	ShowExcluded bool
+	// about how many axis ticks to suggest, 0 for none
+	Ticks int
+	// fraction of the data range to add on each side of the axis bounds
+	Pad float64
+	// "influence" for the leverage and Cook's distance of each point
+	Diagnostics string
+	// report how long parsing and fitting took
+	Timing bool
+	// "density" to send a DensityGrid of GridSize by GridSize cells for
+	// drawing a heatmap rather than the points
+	Render   string
+	GridSize int
+	// when set, replaces the parsed points, e.g. by those of a whole session
+	Accumulate func(series []compute.Point) ([]compute.Point, error)
+	// when set, called with the points to fit before any fitting is done
+	OnParsed func(series []compute.Point)
+}
+
+func optionsFromRequest(req *http.Request) (options *Options, err error) {
+	options = &Options{Metadata: metadataFromRequest(req), Seed: time.Now().UnixNano(), Regression: true}
+	if regression := req.FormValue("regression"); regression != "" {
+		if options.Regression, err = strconv.ParseBool(regression); err != nil {
+			return nil, err
+		}
+	}
+	if options.Bootstrap, err = intParam(req, "bootstrap", 0); err != nil {
+		return nil, err
+	}
+	if options.Bootstrap > config.MaxBootstrap {
+		options.Bootstrap = config.MaxBootstrap
+	}
+	if options.Method = req.FormValue("method"); options.Method == "" {
+		options.Method = "ols"
+	}
+	if _, ok := fitModels[options.Method]; !ok {
+		return nil, errUnknownMethod
+	}
+	options.Params = req.Form
+	switch options.FitSpace = req.FormValue("fitSpace"); options.FitSpace {
+	case "", "linear":
+	case "loglog":
+		if options.Method != "ols" {
+			return nil, errBadFitSpace
+		}
+	default:
+		return nil, errBadFitSpace
+	}
+	switch options.RegressionType = req.FormValue("regressionType"); options.RegressionType {
+	case "":
+	case "auto":
+		if options.Method != "ols" || options.FitSpace == "loglog" {
+			return nil, errBadRegressionType
+		}
+	default:
+		return nil, errBadRegressionType
+	}
+	if options.Trim, err = floatParam(req, "trim", 0); err != nil {
+		return nil, err
+	}
+	if options.Trim < 0 || options.Trim > compute.MaxTrim {
+		return nil, errBadTrim
+	}
+	options.Parse.CSVMode = req.FormValue("csvMode")
+	options.Parse.XColumn = req.FormValue("xcol")
+	options.Parse.YColumn = req.FormValue("ycol")
+	if options.Parse.MaxLines, err = intParam(req, "maxLines", config.MaxPoints); err != nil {
+		return nil, err
+	}
+	if options.Parse.MaxLines < 1 || options.Parse.MaxLines > config.MaxPoints {
+		options.Parse.MaxLines = config.MaxPoints
+	}
+	if strict := req.FormValue("strictParse"); strict != "" {
+		if options.Parse.Strict, err = strconv.ParseBool(strict); err != nil {
+			return nil, err
+		}
+	}
+	if withLineNumbers := req.FormValue("withLineNumbers"); withLineNumbers != "" {
+		if options.Parse.LineNumbers, err = strconv.ParseBool(withLineNumbers); err != nil {
+			return nil, err
+		}
+	}
+	options.InputCRS = req.FormValue("inputCRS")
+	if options.InputCRS != "" && options.InputCRS != "WGS84" {
+		return nil, errUnknownCRS
+	}
+	if seed := req.FormValue("seed"); seed != "" {
+		if options.Seed, err = strconv.ParseInt(seed, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	budget, err := intParam(req, "timeBudgetMs", 0)
+	if err != nil {
+		return nil, err
+	}
+	options.TimeBudget = time.Duration(budget) * time.Millisecond
+	if options.XMin, err = floatParam(req, "xmin", math.Inf(-1)); err != nil {
+		return nil, err
+	}
+	if options.XMax, err = floatParam(req, "xmax", math.Inf(1)); err != nil {
+		return nil, err
+	}
+	if options.Ticks, err = intParam(req, "ticks", 0); err != nil {
+		return nil, err
+	}
+	if options.Ticks < 0 || options.Ticks > maxTicks {
+		return nil, errBadTicks
+	}
+	if options.Pad, err = floatParam(req, "pad", config.AxisPadding); err != nil {
+		return nil, err
+	}
+	if options.Pad < 0 || options.Pad > 1 {
+		return nil, errBadPad
+	}
+	switch options.Diagnostics = req.FormValue("diagnostics"); options.Diagnostics {
+	case "", "influence":
+	default:
+		return nil, errUnknownDiagnostics
+	}
+	switch options.Render = req.FormValue("render"); options.Render {
+	case "", "points":
+	case "density":
+		if options.GridSize, err = intParam(req, "gridSize", config.DensityGridSize); err != nil {
+			return nil, err
+		}
+		if options.GridSize < 1 || options.GridSize > maxGridSize {
+			return nil, errBadGridSize
+		}
+	default:
+		return nil, errUnknownRender
+	}
+	if timing := req.FormValue("timing"); timing != "" {
+		if options.Timing, err = strconv.ParseBool(timing); err != nil {
+			return nil, err
+		}
+	}
+	if showExcluded := req.FormValue("showExcluded"); showExcluded != "" {
+		if options.ShowExcluded, err = strconv.ParseBool(showExcluded); err != nil {
+			return nil, err
 		}
-		// ignore conversion errors
-		x, _ := strconv.ParseFloat(record[0], 64)
-		y, _ := strconv.ParseFloat(record[1], 64)
-		series = append(series, Point{X: x, Y: y})
 	}
+	return options, nil
+}
 
-	slope, intercept, stdError, correlation := linearRegression(series)
+// reads the X-Request-Timeout header, e.g. "30s"; 0 when absent
+func requestTimeout(req *http.Request) (time.Duration, error) {
+	header := req.Header.Get("X-Request-Timeout")
+	if header == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(header)
+	if err == nil && timeout <= 0 {
+		err = errBadTimeout
+	}
+	return timeout, err
+}
 
-	dataSample := &DataSample{Series: series,
-		RegressionLine: RegressionLine{Slope: slope,
-			Intercept:   intercept,
-			StdError:    stdError,
-			Correlation: correlation}}
+// runs dataSampleProcess, giving up with context.DeadlineExceeded after
+// timeout (0 for none). Not every fitting loop checks the context, so the
+// processing runs on its own goroutine; it stops at its next check.
+func dataSampleProcessWithin(ctx context.Context, timeout time.Duration, src string, options *Options) (*compute.DataSample, error) {
+	if timeout == 0 {
+		return dataSampleProcess(ctx, src, options)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	type result struct {
+		dataSample *compute.DataSample
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		dataSample, err := dataSampleProcess(ctx, src, options)
+		done <- result{dataSample, err}
+	}()
+	select {
+	case r := <-done:
+		return r.dataSample, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// how many of the parsed points a debug log line shows
+const debugPoints = 10
 
-	jsonDataSample, err := json.Marshal(dataSample)
+// debug output, as key=value lines on stderr
+var debugLog = slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+// logs what the regression is about to run on; an empty series here means
+// no line of the input parsed
+func logParsed(series []compute.Point) {
+	shown := series
+	if len(shown) > debugPoints {
+		shown = shown[:debugPoints]
+	}
+	sums := compute.SeriesSums(series)
+	debugLog.Info("parsed series",
+		"count", len(series),
+		"first", fmt.Sprint(shown),
+		"sumX", sums.SumX,
+		"sumY", sums.SumY,
+		"sumXY", sums.SumXY,
+		"sumX2", sums.SumX2,
+		"xmean", sums.XMean,
+		"ymean", sums.YMean)
+}
+
+// processes data samples, sends back data to plot along with regression lines
+func dataSampleProcess(ctx context.Context, src string, options *Options) (dataSample *compute.DataSample, err error) {
+	// time.Now carries the monotonic clock, so the durations are immune to
+	// wall clock changes
+	var start, parsedAt, fitStart, fittedAt time.Time
+	if options.Timing {
+		start = time.Now()
+	}
+	parsed, err := compute.ParseColumnsContext(ctx, src, options.Parse)
 	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	return string(jsonDataSample)
-}
-
-// perform linear regression on the data series
-// based on Numerical Methods for Engineers, 2nd ed. by Chapra & Canal
-func linearRegression(series []Point) (slope float64, intercept float64, stdError float64, correlation float64) {
-	len := len(series)
-	flen := float64(len) // convenience
-	sumx := 0.0
-	sumy := 0.0
-	sumxy := 0.0
-	sumx2 := 0.0
-	for ix := 0; ix < len; ix++ {
-		x := series[ix].X
-		y := series[ix].Y
-		sumx += x
-		sumy += y
-		sumxy += x * y
-		sumx2 += x * x
-	}
-	xmean := sumx / flen
-	ymean := sumy / flen
-	slope = (flen*sumxy - sumx*sumy) / (flen*sumx2 - sumx*sumx)
-	intercept = ymean - slope*xmean
-
-	st := 0.0
-	sr := 0.0
-	for ix := 0; ix < len; ix++ {
-		x := series[ix].X
-		y := series[ix].Y
-		st += (y - ymean) * (y - ymean)
-		// guessing the compiler sees this is constant & does sth faster than exponentiation
-		sr += (y - (slope*x - intercept)) * (y - (slope*x - intercept))
-	}
-	stdError = (math.Sqrt((sr / (flen - 2.0)))) // todo: must check that min 2 points are supplied
-	correlation = (math.Sqrt(((st - sr) / st)))
-	return slope, intercept, stdError, correlation
+		return nil, err
+	}
+	if options.Timing {
+		parsedAt = time.Now()
+	}
+	series, excluded := compute.FilterXRange(parsed.Series, options.XMin, options.XMax)
+	if options.Accumulate != nil {
+		if series, err = options.Accumulate(series); err != nil {
+			return nil, err
+		}
+	}
+	if config.Debug {
+		logParsed(series)
+	}
+	if options.OnParsed != nil {
+		options.OnParsed(series)
+	}
+
+	if options.Timing {
+		fitStart = time.Now()
+	}
+	dataSample = &compute.DataSample{Series: series, Envelope: compute.NewEnvelope(), Metadata: options.Metadata}
+	if options.Regression {
+		if options.FitSpace == "loglog" {
+			line, err := compute.LogLogFit(series)
+			if err != nil {
+				return nil, err
+			}
+			dataSample.RegressionLine = &line
+		} else if options.RegressionType == "auto" {
+			line, selected, scores := compute.SelectByShape(series)
+			dataSample.RegressionLine, dataSample.AutoSelectedType, dataSample.HeuristicScores = &line, selected, scores
+		} else if dataSample.RegressionLine, err = fitModel(options.Method, series, options.Params); err != nil {
+			return nil, err
+		}
+		linear := dataSample.RegressionLine.Model == ""
+		if options.Method == "ols" && linear && options.Trim > 0 {
+			line, untrimmed, trimmed := compute.TrimmedFit(series, options.Trim)
+			dataSample.RegressionLine, dataSample.UntrimmedLine, dataSample.TrimmedCount = &line, &untrimmed, trimmed
+		}
+		regressionCount.Add(1)
+		// the score is made for straight lines
+		if linear {
+			dataSample.QualityScore, dataSample.QualityGrade = compute.Quality(series, *dataSample.RegressionLine, config.QualityWeights)
+		}
+		// of the least squares line, whatever the method
+		if options.Diagnostics == "influence" {
+			if dataSample.Influence, err = compute.InfluenceOf(series); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if options.Timing {
+		fittedAt = time.Now()
+	}
+	dataSample.ColumnNames = parsed.ColumnNames
+	dataSample.SkippedLines, dataSample.ParseError = parsed.SkippedLines, parsed.FirstError
+	dataSample.ExcludedCount = len(excluded)
+	if options.ShowExcluded {
+		dataSample.ExcludedPoints = excluded
+	}
+	// the client may have gone while fitting
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if options.Regression && options.Bootstrap > 0 {
+		budget := ctx
+		if options.TimeBudget > 0 {
+			var cancel context.CancelFunc
+			budget, cancel = context.WithTimeout(ctx, options.TimeBudget)
+			defer cancel()
+		}
+		dataSample.BootstrapSlopeInterval, dataSample.Approximate = compute.BootstrapSlopeContext(budget, series, options.Bootstrap, options.Seed)
+	}
+	if options.Render == "density" {
+		dataSample.Density = compute.Density(series, options.GridSize)
+	}
+	if len(series) > 0 {
+		bounds := compute.PaddedBounds(series, options.Pad)
+		dataSample.AxisBounds = &bounds
+		if options.Ticks > 0 {
+			dataSample.XTicks = compute.NiceTicks(bounds.XMin, bounds.XMax, options.Ticks)
+			dataSample.YTicks = compute.NiceTicks(bounds.YMin, bounds.YMax, options.Ticks)
+		}
+	}
+	if options.InputCRS == "WGS84" {
+		if dataSample.GeoBounds, dataSample.Metadata.Geo, err = compute.GeoSummary(series); err != nil {
+			return nil, &compute.ParseError{Err: err}
+		}
+	}
+	if options.Timing {
+		dataSample.Timing = &compute.Timing{ParseMs: milliseconds(parsedAt.Sub(start)),
+			RegressionMs: milliseconds(fittedAt.Sub(fitStart)),
+			TotalMs:      milliseconds(time.Since(start))}
+	}
+	return dataSample, nil
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
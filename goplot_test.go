@@ -0,0 +1,447 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"goplot/compute"
+	. "goplot/constants"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runs the tests against the default config, with series stored in a
+// temporary DataDir. With GOPLOT_TEST_MAIN=1 the test binary runs goplot
+// itself instead, for the tests of how it exits.
+func TestMain(m *testing.M) {
+	if os.Getenv("GOPLOT_TEST_MAIN") == "1" {
+		main()
+		os.Exit(EXIT_SUCCESS)
+	}
+	dataDir, err := os.MkdirTemp("", "goplot-test")
+	if err != nil {
+		panic(err)
+	}
+	config = defaultConfig()
+	config.DataDir = dataDir
+	config.CustomLog = "nolog"
+	seriesLocks = NewSeriesLockManager()
+	seriesNamePattern = regexp.MustCompile(config.SeriesNamePattern)
+	sessions = NewSessionStore(time.Hour)
+	analytics = NewRequestAnalytics(config.AnalyticsBufferSize)
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
+// sends the form to handler as a POST to target
+func postForm(handler http.HandlerFunc, target string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+// the exit code of goplot run with args, from a config file holding
+// configJSON unless it is ""
+func exitCode(t *testing.T, configJSON string, args ...string) int {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "goplot.conf")
+	if configJSON != "" {
+		if err := os.WriteFile(configPath, []byte(configJSON), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(os.Args[0], append([]string{"-c", configPath}, args...)...)
+	cmd.Env = append(os.Environ(), "GOPLOT_TEST_MAIN=1")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("goplot didn't exit")
+	}
+	return cmd.ProcessState.ExitCode()
+}
+
+func TestStartupExitCodes(t *testing.T) {
+	// something to be in the way of a listener and a DataDir
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	notADir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notADir, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dataDir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		configJSON string
+		args       []string
+		want       int
+	}{
+		{"missing config", "", nil, EXIT_NO_CONFIG},
+		{"config not JSON", "{", nil, EXIT_CONFIG_PARSE},
+		{"invalid setting", `{"CustomLog": "nolog", "StaleAfterMinutes": -1}`, nil, EXIT_CONFIG_PARSE},
+		{"unknown log token", `{"CustomLog": "nolog", "LogFormat": ["RemoteHost", "Bogus"]}`, nil, EXIT_CONFIG_PARSE},
+		{"DataDir under a file", `{"CustomLog": "nolog", "DataDir": "` + notADir + `/data"}`, nil, EXIT_DATA_DIR_ERROR},
+		{"address in use", `{"CustomLog": "nolog", "DataDir": "` + dataDir + `"}`, []string{"-l", busy.Addr().String()}, EXIT_CANT_LISTEN},
+		{"init over an existing config", `{}`, []string{"init"}, EXIT_INIT_FAILED},
+		// the test's stdin isn't a terminal, and no GOPLOT_ answers are set
+		{"init without a terminal", "", []string{"init"}, EXIT_INIT_FAILED},
+	}
+	for _, test := range tests {
+		if got := exitCode(t, test.configJSON, test.args...); got != test.want {
+			t.Errorf("%s: exited with %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+// posts the form to /goplot/viz and decodes the JSON answer
+func postViz(t *testing.T, form url.Values) compute.DataSample {
+	t.Helper()
+	rec := postForm(dataSampleServer, "/goplot/viz", form)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var dataSample compute.DataSample
+	if err := json.Unmarshal(rec.Body.Bytes(), &dataSample); err != nil {
+		t.Fatal(err)
+	}
+	return dataSample
+}
+
+// the SVG axis titles are drawn by client/graph.js from this metadata
+func TestVizMetadata(t *testing.T) {
+	want := compute.Metadata{XLabel: "time", XUnit: "s", YLabel: "distance", YUnit: "m"}
+	dataSample := postViz(t, url.Values{"dataseries": {"1,2\n2,4\n3,7"},
+		"xlabel": {want.XLabel}, "xunit": {want.XUnit}, "ylabel": {want.YLabel}, "yunit": {want.YUnit}})
+	if dataSample.Metadata != want {
+		t.Errorf("got %+v, want %+v", dataSample.Metadata, want)
+	}
+	if dataSample := postViz(t, url.Values{"dataseries": {"1,2\n2,4\n3,7"}}); dataSample.Metadata != (compute.Metadata{}) {
+		t.Errorf("got %+v without labels, want them empty", dataSample.Metadata)
+	}
+}
+
+func TestVizWithoutRegression(t *testing.T) {
+	before := regressionCount.Value()
+	rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {"1,2\n2,4\n3,7"}, "regression": {"0"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["regressionLine"]; ok {
+		t.Errorf("regressionLine present: %s", rec.Body)
+	}
+	if got := regressionCount.Value(); got != before {
+		t.Errorf("%d regressions run, want none", got-before)
+	}
+
+	if postViz(t, url.Values{"dataseries": {"1,2\n2,4\n3,7"}}).RegressionLine == nil || regressionCount.Value() != before+1 {
+		t.Error("no regression by default")
+	}
+}
+
+func TestVizTimeBudget(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, 2*i+i%7)
+	}
+	form := url.Values{"dataseries": {data.String()}, "bootstrap": {"10000"}, "seed": {"1"}, "timeBudgetMs": {"1"}}
+	if dataSample := postViz(t, form); !dataSample.Approximate {
+		t.Error("10000 resamples of 5000 points fit in 1ms")
+	}
+}
+
+func TestVizRequestTimeout(t *testing.T) {
+	var data strings.Builder
+	// short lines, to stay under the 10 MB form limit
+	for i := 0; i < 1000000; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i%10, 2*(i%10)+i%3)
+	}
+	body := url.Values{"dataseries": {data.String()}}.Encode()
+	post := func(timeout string) int {
+		req := httptest.NewRequest("POST", "/goplot/viz", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Request-Timeout", timeout)
+		rec := httptest.NewRecorder()
+		dataSampleServer(rec, req)
+		return rec.Code
+	}
+	if got := post("1ms"); got != http.StatusGatewayTimeout {
+		t.Errorf("1M points in 1ms: got %d, want %d", got, http.StatusGatewayTimeout)
+	}
+	if got := post("1h"); got != http.StatusOK {
+		t.Errorf("1M points in an hour: got %d, want %d", got, http.StatusOK)
+	}
+	for _, timeout := range []string{"0s", "-1s", "soon"} {
+		if got := post(timeout); got != http.StatusBadRequest {
+			t.Errorf("X-Request-Timeout %q: got %d, want %d", timeout, got, http.StatusBadRequest)
+		}
+	}
+}
+
+// a client that hangs up mid-parse gets no answer, and the work stops
+func TestVizCancelled(t *testing.T) {
+	var data strings.Builder
+	for i := 0; i < 200000; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, 2*i)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	options := &Options{}
+	if _, err := dataSampleProcess(ctx, data.String(), options); err != context.Canceled {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+
+	form := url.Values{"dataseries": {data.String()}}
+	req := httptest.NewRequest("POST", "/goplot/viz", strings.NewReader(form.Encode())).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	dataSampleServer(rec, req)
+	if rec.Body.Len() != 0 {
+		t.Errorf("answered a cancelled request: %d %.200s", rec.Code, rec.Body)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v", elapsed)
+	}
+}
+
+func TestVizFormTooLarge(t *testing.T) {
+	form := url.Values{"dataseries": {strings.Repeat("1,2\n", 3<<20)}}
+	if rec := postForm(dataSampleServer, "/goplot/viz", form); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("got %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestVizEquation(t *testing.T) {
+	form := url.Values{"dataseries": {"0,0.5\n1,2.5\n2,4.4\n3,6.6"}}
+	if got := postViz(t, form).RegressionLine.Equation; got != "y = 2.02x + 0.47 (R² = 0.999)" {
+		t.Errorf("got %q", got)
+	}
+	saved := compute.EquationPrecision
+	defer func() { compute.EquationPrecision = saved }()
+	compute.EquationPrecision = 5
+	if got := postViz(t, form).RegressionLine.Equation; got != "y = 2.02x + 0.47 (R² = 0.99912)" {
+		t.Errorf("at 5 digits: got %q", got)
+	}
+}
+
+func TestVizDebugLog(t *testing.T) {
+	var logged bytes.Buffer
+	savedLog, savedDebug := debugLog, config.Debug
+	defer func() { debugLog, config.Debug = savedLog, savedDebug }()
+	debugLog = slog.New(slog.NewTextHandler(&logged, nil))
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7"}}
+
+	postViz(t, form)
+	if logged.Len() != 0 {
+		t.Errorf("logged without Debug: %s", &logged)
+	}
+	config.Debug = true
+	postViz(t, form)
+	for _, want := range []string{"parsed series", "count=3", "sumX=6", "sumY=13", "sumXY=31", "sumX2=14", "xmean=2"} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("no %q in %s", want, &logged)
+		}
+	}
+}
+
+func TestVizStrictParse(t *testing.T) {
+	data := "1,2\n2,4\n3,x\n4,8"
+	rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}, "strictParse": {"1"}})
+	if rec.Code != http.StatusBadRequest || !strings.HasPrefix(rec.Body.String(), "line 3: ") {
+		t.Errorf("strict: got %d %q, want 400 about line 3", rec.Code, rec.Body)
+	}
+	// lenient by default
+	if dataSample := postViz(t, url.Values{"dataseries": {data}}); len(dataSample.Series) != 3 || dataSample.SkippedLines != 1 {
+		t.Errorf("lenient: got %d points and %d skipped lines, want 3 and 1", len(dataSample.Series), dataSample.SkippedLines)
+	}
+}
+
+func TestVizTiming(t *testing.T) {
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7"}}
+	if dataSample := postViz(t, form); dataSample.Timing != nil {
+		t.Errorf("timing without timing=1: %+v", dataSample.Timing)
+	}
+	form.Set("timing", "1")
+	timing := postViz(t, form).Timing
+	if timing == nil {
+		t.Fatal("no timing with timing=1")
+	}
+	if timing.ParseMs < 0 || timing.RegressionMs < 0 || timing.TotalMs < timing.ParseMs+timing.RegressionMs {
+		t.Errorf("got %+v, want non-negative parts within the total", *timing)
+	}
+}
+
+// charts are drawn by graph.js in the browser; the client files it needs
+// are what can be cached, and a revalidation costs no body
+func TestClientFileConditionalGet(t *testing.T) {
+	handler := fileServe("graph.js")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/goplot/graph.js", nil))
+	lastModified := rec.Header().Get("Last-Modified")
+	if rec.Code != http.StatusOK || lastModified == "" {
+		t.Fatalf("got %d, Last-Modified %q", rec.Code, lastModified)
+	}
+	req := httptest.NewRequest("GET", "/goplot/graph.js", nil)
+	req.Header.Set("If-Modified-Since", lastModified)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidating: got %d with %d bytes, want %d", rec.Code, rec.Body.Len(), http.StatusNotModified)
+	}
+}
+
+func TestVizLogLog(t *testing.T) {
+	// y = 2·x^3
+	form := url.Values{"dataseries": {"1,2\n2,16\n3,54\n4,128"}, "fitSpace": {"loglog"}}
+	if c := postViz(t, form).RegressionLine.Coefficients; len(c) != 2 || math.Abs(c[0]-2) > 1e-9 || math.Abs(c[1]-3) > 1e-9 {
+		t.Errorf("coefficients %v, want [2 3]", c)
+	}
+	form.Set("dataseries", "1,2\n2,16\n3,-54")
+	if rec := postForm(dataSampleServer, "/goplot/viz", form); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("negative y: got %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestVizMaxLines(t *testing.T) {
+	data := "1,2\n2,4\n3,7\n4,8\n5,11"
+	rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}, "maxLines": {"3"}})
+	if want := "line 4: more lines than maxLines\n"; rec.Code != http.StatusBadRequest || rec.Body.String() != want {
+		t.Errorf("over the cap: got %d %q, want 400 %q", rec.Code, rec.Body, want)
+	}
+	if dataSample := postViz(t, url.Values{"dataseries": {data}, "maxLines": {"5"}}); len(dataSample.Series) != 5 {
+		t.Errorf("at the cap: got %d points, want 5", len(dataSample.Series))
+	}
+
+	// bounded by MaxPoints
+	saved := config.MaxPoints
+	defer func() { config.MaxPoints = saved }()
+	config.MaxPoints = 4
+	if rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}, "maxLines": {"100"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("over MaxPoints: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestVizXRange(t *testing.T) {
+	// a line in the middle, with a warm-up and a tail that are way off it
+	data := "0,50\n1,-20\n2,40\n3,7\n4,9\n5,11\n6,13\n7,-5\n8,90\n9,0"
+	dataSample := postViz(t, url.Values{"dataseries": {data}, "xmin": {"3"}, "xmax": {"6"}, "showExcluded": {"true"}})
+	line := dataSample.RegressionLine
+	if math.Abs(line.Slope-2) > 1e-9 || math.Abs(line.Intercept-1) > 1e-9 {
+		t.Errorf("fitted y = %vx + %v, want y = 2x + 1", line.Slope, line.Intercept)
+	}
+	if len(dataSample.Series) != 4 || dataSample.ExcludedCount != 6 || len(dataSample.ExcludedPoints) != 6 {
+		t.Errorf("got %d points, %d excluded and %d excluded points returned, want 4, 6 and 6",
+			len(dataSample.Series), dataSample.ExcludedCount, len(dataSample.ExcludedPoints))
+	}
+	if dataSample := postViz(t, url.Values{"dataseries": {data}, "xmin": {"3"}, "xmax": {"6"}}); dataSample.ExcludedPoints != nil {
+		t.Errorf("excluded points returned without showExcluded: %v", dataSample.ExcludedPoints)
+	}
+}
+
+func TestVizPad(t *testing.T) {
+	data := "0,0\n2,8\n4,4"
+	if got, want := *postViz(t, url.Values{"dataseries": {data}}).AxisBounds, (compute.AxisBounds{XMin: -0.2, XMax: 4.2, YMin: -0.4, YMax: 8.4}); got != want {
+		t.Errorf("default padding: got %+v, want %+v", got, want)
+	}
+	if got, want := *postViz(t, url.Values{"dataseries": {data}, "pad": {"0.25"}}).AxisBounds, (compute.AxisBounds{XMin: -1, XMax: 5, YMin: -2, YMax: 10}); got != want {
+		t.Errorf("pad=0.25: got %+v, want %+v", got, want)
+	}
+	for _, pad := range []string{"-0.1", "1.5", "wide"} {
+		if rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}, "pad": {pad}}); rec.Code != http.StatusBadRequest {
+			t.Errorf("pad=%s: got %d, want %d", pad, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestVizLineNumbers(t *testing.T) {
+	data := "1,2\nnot,a point\n2,4\n\n3,7"
+	dataSample := postViz(t, url.Values{"dataseries": {data}, "withLineNumbers": {"1"}})
+	var lines []int
+	for _, pt := range dataSample.Series {
+		lines = append(lines, pt.Line)
+	}
+	if want := []int{1, 3, 5}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("got lines %v, want %v", lines, want)
+	}
+	rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}})
+	if strings.Contains(rec.Body.String(), `"line"`) {
+		t.Errorf("line numbers without withLineNumbers: %s", rec.Body)
+	}
+	if rec := postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}, "withLineNumbers": {"yes"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("withLineNumbers=yes: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// every JSON response is wrapped in the versioned envelope
+func TestAPIVersion(t *testing.T) {
+	data := "1,2\n2,4\n3,7"
+	responses := map[string]*httptest.ResponseRecorder{
+		"viz":         postForm(dataSampleServer, "/goplot/viz", url.Values{"dataseries": {data}}),
+		"auto":        postForm(autoServer, "/goplot/auto", url.Values{"dataseries": {data}}),
+		"intersect":   postForm(intersectServer, "/goplot/intersect", url.Values{"series1": {data}, "series2": {"1,1\n2,1\n3,2"}}),
+		"stft":        postForm(stftServer, "/goplot/stft?windowSize=2", url.Values{"dataseries": {data}}),
+		"interpolate": postForm(interpolateServer, "/goplot/interpolate?step=0.5", url.Values{"dataseries": {data}}),
+		"cumulative":  postForm(cumulativeServer, "/goplot/cumulative", url.Values{"dataseries": {data}}),
+		"readyz":      httptest.NewRecorder(),
+		"pipe":        httptest.NewRecorder(),
+		"series":      httptest.NewRecorder(),
+	}
+	readyzServer(responses["readyz"], httptest.NewRequest("GET", "/readyz", nil))
+	pipeServer(responses["pipe"], httptest.NewRequest("POST", "/goplot/pipe", strings.NewReader(`[{"op": "parse", "data": "1,2"}]`)))
+	seriesListServer(responses["series"], httptest.NewRequest("GET", "/goplot/series", nil))
+	for name, rec := range responses {
+		var envelope compute.Envelope
+		if err := json.Unmarshal(rec.Body.Bytes(), &envelope); err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if envelope.APIVersion != "1" {
+			t.Errorf("%s: apiVersion %q, want \"1\"", name, envelope.APIVersion)
+		}
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	if err := selfTest(); err != nil {
+		t.Fatalf("the embedded self-test fails: %v", err)
+	}
+	saved := selfTestJson
+	defer func() { selfTestJson = saved }()
+	for _, bad := range []string{
+		`{"Series": [{"x": 0, "y": 1}, {"x": 1, "y": 3}, {"x": 2, "y": 5}], "Slope": 2.0000001, "Intercept": 1}`,
+		`{"Series": [{"x": 0, "y": 1}, {"x": 1, "y": 3}, {"x": 2, "y": 5}], "Slope": 2, "Intercept": 1.0000001}`,
+		`not JSON`,
+	} {
+		selfTestJson = []byte(bad)
+		if err := selfTest(); err == nil {
+			t.Errorf("%s passed", bad)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// tests whether series2 Granger-causes series1
+// POST /goplot/granger?lags=2 with series1 and series2
+func grangerServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	lags, err := intParam(req, "lags", 1)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	series1, series2, err := parseTwoSeries(req)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	result, err := compute.GrangerCausality(series1, series2, lags)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonResult, err := json.Marshal(result)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonResult)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+// a readiness check returns nil when the dependency is usable
+type readinessCheck struct {
+	name  string
+	check func() error
+}
+
+var readinessChecks = []readinessCheck{
+	{"logfile", checkLogWritable},
+	{"datadir", checkDataDirWritable},
+}
+
+type Readiness struct {
+	compute.Envelope
+	Ready  bool              `json:"ready"`
+	Failed map[string]string `json:"failed,omitempty"`
+	// stored series that have stopped receiving updates; they don't make
+	// the server unready
+	StaleSeries []string `json:"staleSeries"`
+}
+
+// liveness: the process is up and serving
+func healthzServer(c http.ResponseWriter, req *http.Request) {
+	c.Header().Set("Content-Type", "text/plain")
+	c.Write([]byte("ok\n"))
+}
+
+// readiness: 200 when every check passes, 503 listing the failures otherwise
+func readyzServer(c http.ResponseWriter, req *http.Request) {
+	readiness := &Readiness{Envelope: compute.NewEnvelope(), Ready: true, StaleSeries: staleSeries()}
+	for _, rc := range readinessChecks {
+		if err := rc.check(); err != nil {
+			if readiness.Failed == nil {
+				readiness.Failed = make(map[string]string)
+			}
+			readiness.Failed[rc.name] = err.Error()
+			readiness.Ready = false
+		}
+	}
+
+	jsonReadiness, err := json.Marshal(readiness)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	if !readiness.Ready {
+		c.WriteHeader(http.StatusServiceUnavailable)
+	}
+	c.Write(jsonReadiness)
+}
+
+func checkLogWritable() error {
+	if config.CustomLog == "nolog" {
+		return nil
+	}
+	log, err := os.OpenFile(config.CustomLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return err
+	}
+	return log.Close()
+}
+
+func checkDataDirWritable() error {
+	if err := os.MkdirAll(config.DataDir, 0755); err != nil {
+		return err
+	}
+	probe, err := ioutil.TempFile(config.DataDir, ".readyz")
+	if err != nil {
+		return err
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// the readiness answer of /readyz, and its status code
+func getReadiness(t *testing.T) (Readiness, int) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	readyzServer(rec, httptest.NewRequest("GET", "/readyz", nil))
+	var readiness Readiness
+	if err := json.Unmarshal(rec.Body.Bytes(), &readiness); err != nil {
+		t.Fatal(err)
+	}
+	return readiness, rec.Code
+}
+
+func TestReadyz(t *testing.T) {
+	if readiness, code := getReadiness(t); code != http.StatusOK || !readiness.Ready || len(readiness.Failed) > 0 {
+		t.Errorf("got %d %+v, want ready", code, readiness)
+	}
+
+	saved := config.CustomLog
+	defer func() { config.CustomLog = saved }()
+	config.CustomLog = filepath.Join(t.TempDir(), "missing", "access.log")
+	readiness, code := getReadiness(t)
+	if code != http.StatusServiceUnavailable || readiness.Ready || readiness.Failed["logfile"] == "" {
+		t.Errorf("with an unwritable log: got %d %+v, want the logfile check failed", code, readiness)
+	}
+	if _, failed := readiness.Failed["datadir"]; failed {
+		t.Errorf("datadir failed too: %v", readiness.Failed)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	// liveness doesn't depend on the readiness checks
+	saved := config.CustomLog
+	defer func() { config.CustomLog = saved }()
+	config.CustomLog = filepath.Join(t.TempDir(), "missing", "access.log")
+	rec := httptest.NewRecorder()
+	healthzServer(rec, httptest.NewRequest("GET", "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("got %d, want 200", rec.Code)
+	}
+}
@@ -1,28 +1,96 @@
 package httplog
 
 import (
+	"bufio"
 	"io"
 	"os"
+	"sync"
+	"time"
 )
 
+// used by New
+const DefaultBufferSize = 4096
+const DefaultFlushInterval = time.Second
+
 type Logger struct {
-	log *os.File
+	mu      sync.Mutex
+	log     *os.File
+	buf     *bufio.Writer
+	done    chan struct{}
+	flushed chan struct{}
 }
 
-// Creates a new Logger
+// Creates a new Logger with the default buffering
 func New(logfile string) (*Logger, error) {
+	return NewBuffered(logfile, DefaultBufferSize, DefaultFlushInterval)
+}
+
+// Creates a new Logger that buffers up to bufferSize bytes and writes them
+// out at least every flushInterval. Close flushes what is left.
+func NewBuffered(logfile string, bufferSize int, flushInterval time.Duration) (*Logger, error) {
+	if flushInterval <= 0 {
+		flushInterval = DefaultFlushInterval
+	}
 	// TODO: config option for setting logfile perms
-	// TODO: need to close this file on exit
 	log, err := os.OpenFile(logfile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 777)
 	if err != nil {
 		return nil, err
 	}
-	return &Logger{log}, err
+	logger := &Logger{log: log,
+		buf:     bufio.NewWriterSize(log, bufferSize),
+		done:    make(chan struct{}),
+		flushed: make(chan struct{})}
+	go logger.flushEvery(flushInterval)
+	return logger, err
+}
+
+func (logger *Logger) flushEvery(interval time.Duration) {
+	defer close(logger.flushed)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			logger.Flush()
+		case <-logger.done:
+			return
+		}
+	}
 }
 
 func (logger *Logger) Write(s []byte) {
-	n, err := logger.log.Write(s)
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if logger.buf == nil {
+		return
+	}
+	n, err := logger.buf.Write(s)
 	if err == nil && n < len(s) {
 		err = io.ErrShortWrite
 	}
 }
+
+// writes out the buffered lines
+func (logger *Logger) Flush() error {
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if logger.buf == nil {
+		return nil
+	}
+	return logger.buf.Flush()
+}
+
+// stops the periodic flush, writes out what is buffered and closes the
+// file. Later writes are dropped.
+func (logger *Logger) Close() error {
+	close(logger.done)
+	<-logger.flushed
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	err := logger.buf.Flush()
+	logger.buf = nil
+	if closeErr := logger.log.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
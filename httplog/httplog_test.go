@@ -0,0 +1,59 @@
+package httplog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBufferedFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewBuffered(path, 4096, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(content)
+	}
+
+	logger.Write([]byte("first\n"))
+	deadline := time.Now().Add(time.Second)
+	for read() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := read(); got != "first\n" {
+		t.Errorf("after the flush interval got %q", got)
+	}
+
+	// Close writes out what the ticker hasn't
+	logger.Write([]byte("second\n"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	logger.Write([]byte("dropped\n"))
+	if got := read(); got != "first\nsecond\n" {
+		t.Errorf("after Close got %q", got)
+	}
+}
+
+func TestWriteIsBuffered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewBuffered(path, 4096, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+	logger.Write([]byte("line\n"))
+	if content, _ := os.ReadFile(path); len(content) != 0 {
+		t.Errorf("written straight through: %q", content)
+	}
+	logger.Flush()
+	if content, _ := os.ReadFile(path); string(content) != "line\n" {
+		t.Errorf("after Flush got %q", content)
+	}
+}
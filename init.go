@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// a question asked by goplot init
+type initQuestion struct {
+	field    string // Config field set by the answer, also read from GOPLOT_<FIELD>
+	prompt   string
+	def      string
+	validate func(answer string) error
+}
+
+var initQuestions = []initQuestion{
+	{"Address", "Listen address (host:port or unix:/path/to.sock)", addressFlagDefault, validateAddress},
+	{"CustomLog", `Access log file ("nolog" for none)`, "nolog", validateLogPath},
+	{"DataDir", "Directory for named series", "data", validateNonEmpty},
+}
+
+// the part of the config file goplot init writes
+type initConfig struct {
+	Address   string
+	CustomLog string
+	DataDir   string
+}
+
+// whether stdin is a terminal, a character device rather than a pipe or a
+// file, so init has someone to ask
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// goplot init: asks for the basic settings and writes them as a config file
+// at path. When stdin is not a terminal nothing is asked: every answer has to
+// be given by its GOPLOT_ environment variable, and the first one missing
+// fails the init. There are no TLS or TOML questions, as the server neither
+// serves TLS nor reads TOML config files.
+func runInit(path string, in io.Reader, out io.Writer, interactive bool) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists, not overwriting it", path)
+	}
+
+	answers := make(map[string]string)
+	scanner := bufio.NewScanner(in)
+	for _, q := range initQuestions {
+		answer, err := ask(q, scanner, out, interactive)
+		if err != nil {
+			return err
+		}
+		answers[q.field] = answer
+	}
+
+	data, err := json.MarshalIndent(initConfig{Address: answers["Address"],
+		CustomLog: answers["CustomLog"],
+		DataDir:   answers["DataDir"]}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "wrote %s\n", path)
+	return nil
+}
+
+// asks q until it gets a valid answer; an empty answer takes the default
+func ask(q initQuestion, scanner *bufio.Scanner, out io.Writer, interactive bool) (string, error) {
+	if !interactive {
+		answer, ok := os.LookupEnv(envPrefix + strings.ToUpper(q.field))
+		if !ok {
+			return "", fmt.Errorf("stdin is not a terminal and %s%s is not set", envPrefix, strings.ToUpper(q.field))
+		}
+		if err := q.validate(answer); err != nil {
+			return "", fmt.Errorf("%s%s: %s", envPrefix, strings.ToUpper(q.field), err.Error())
+		}
+		return answer, nil
+	}
+	for {
+		fmt.Fprintf(out, "%s [%s]: ", q.prompt, q.def)
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", err
+			}
+			return "", io.ErrUnexpectedEOF
+		}
+		answer := strings.TrimSpace(scanner.Text())
+		if answer == "" {
+			answer = q.def
+		}
+		if err := q.validate(answer); err != nil {
+			fmt.Fprintf(out, "  %s\n", err.Error())
+			continue
+		}
+		return answer, nil
+	}
+}
+
+func validateAddress(address string) error {
+	if strings.HasPrefix(address, unixAddressPrefix) {
+		return validateNonEmpty(strings.TrimPrefix(address, unixAddressPrefix))
+	}
+	_, err := net.ResolveTCPAddr("tcp", address)
+	return err
+}
+
+// the log file's directory has to exist
+func validateLogPath(path string) error {
+	if path == "nolog" {
+		return nil
+	}
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", filepath.Dir(path))
+	}
+	return nil
+}
+
+func validateNonEmpty(answer string) error {
+	if answer == "" {
+		return errors.New("can't be empty")
+	}
+	return nil
+}
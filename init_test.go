@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// reads back the config runInit wrote
+func readInitConfig(t *testing.T, path string) initConfig {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written initConfig
+	if err := json.Unmarshal(data, &written); err != nil {
+		t.Fatal(err)
+	}
+	return written
+}
+
+func TestInitInteractive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "server.conf")
+	// a bad address is asked again; an empty answer takes the default
+	in := strings.NewReader("not an address\n127.0.0.1:9000\n" + filepath.Join(dir, "access.log") + "\n\n")
+	var out strings.Builder
+	if err := runInit(path, in, &out, true); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "Listen address") != 2 {
+		t.Errorf("the bad address wasn't asked again:\n%s", out.String())
+	}
+	want := initConfig{Address: "127.0.0.1:9000", CustomLog: filepath.Join(dir, "access.log"), DataDir: "data"}
+	if got := readInitConfig(t, path); got != want {
+		t.Errorf("wrote %+v, want %+v", got, want)
+	}
+
+	if err := runInit(path, strings.NewReader("\n\n\n"), &out, true); err == nil {
+		t.Error("overwrote the existing config")
+	}
+}
+
+func TestInitNotATerminal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.conf")
+	t.Setenv("GOPLOT_ADDRESS", "127.0.0.1:9000")
+	t.Setenv("GOPLOT_CUSTOMLOG", "nolog")
+	t.Setenv("GOPLOT_DATADIR", "")
+	os.Unsetenv("GOPLOT_DATADIR")
+
+	// without a terminal nothing is asked, and a missing answer fails
+	var out strings.Builder
+	if err := runInit(path, strings.NewReader("\n\n\n"), &out, false); err == nil || !strings.Contains(err.Error(), "GOPLOT_DATADIR") {
+		t.Errorf("got %v, want an error naming GOPLOT_DATADIR", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("prompted: %q", out.String())
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("wrote a config")
+	}
+
+	t.Setenv("GOPLOT_DATADIR", "series")
+	if err := runInit(path, strings.NewReader(""), &out, false); err != nil {
+		t.Fatal(err)
+	}
+	want := initConfig{Address: "127.0.0.1:9000", CustomLog: "nolog", DataDir: "series"}
+	if got := readInitConfig(t, path); got != want {
+		t.Errorf("wrote %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// resamples the posted data series at evenly spaced X values, filling gaps
+// POST /goplot/interpolate?method=linear&step=1.0
+func interpolateServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	step, err := floatParam(req, "step", 1)
+	if err != nil || !(step > 0) {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	methodName := req.FormValue("method")
+	if methodName == "" {
+		methodName = "linear"
+	}
+	method, ok := compute.InterpolationMethods[methodName]
+	if !ok {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	interpolated, err := compute.Interpolate(series, step, method)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonInterpolated, err := json.Marshal(struct {
+		compute.Envelope
+		Series []compute.Point `json:"series"`
+	}{compute.NewEnvelope(), interpolated})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonInterpolated)
+}
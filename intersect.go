@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+)
+
+var errMissingSeries = errors.New("both series1 and series2 are required")
+
+// fits both posted datasets and solves for the crossing point
+// POST /goplot/intersect with series1 and series2
+func intersectServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	series1, series2, err := parseTwoSeries(req)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	line1, line2 := seriesFit(series1), seriesFit(series2)
+	if line1 == nil || line2 == nil {
+		serveError(c, req, http.StatusUnprocessableEntity)
+		return
+	}
+
+	intersection := compute.IntersectLines(*line1, *line2)
+	jsonIntersection, err := json.Marshal(intersection)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonIntersection)
+}
+
+// reads the series1 and series2 form fields used by the two-dataset endpoints
+func parseTwoSeries(req *http.Request) (series1 []compute.Point, series2 []compute.Point, err error) {
+	src1, src2 := req.FormValue("series1"), req.FormValue("series2")
+	if src1 == "" || src2 == "" {
+		return nil, nil, errMissingSeries
+	}
+	if series1, err = compute.ParseSeries(src1); err != nil {
+		return nil, nil, err
+	}
+	if series2, err = compute.ParseSeries(src2); err != nil {
+		return nil, nil, err
+	}
+	return series1, series2, nil
+}
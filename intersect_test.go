@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestIntersectServer(t *testing.T) {
+	// y = 2x + 1 and y = -x + 7 cross at (2, 5)
+	rec := postForm(intersectServer, "/goplot/intersect", url.Values{"series1": {"0,1\n1,3\n3,7"}, "series2": {"0,7\n1,6\n4,3"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var intersection compute.Intersection
+	if err := json.Unmarshal(rec.Body.Bytes(), &intersection); err != nil {
+		t.Fatal(err)
+	}
+	if intersection.Point == nil || math.Abs(intersection.Point.X-2) > 1e-9 || math.Abs(intersection.Point.Y-5) > 1e-9 {
+		t.Errorf("got %+v, want the point (2, 5)", intersection.Point)
+	}
+
+	if rec := postForm(intersectServer, "/goplot/intersect", url.Values{"series1": {"0,1\n1,3"}}); rec.Code != http.StatusBadRequest {
+		t.Errorf("without series2: got %d, want 400", rec.Code)
+	}
+	// lines that can't be fitted
+	for _, series2 := range []string{"0,5\n1,5\n2,5", "1,2\n1,4\n1,7", "0,7\n1,6"} {
+		rec := postForm(intersectServer, "/goplot/intersect", url.Values{"series1": {"0,1\n1,3\n3,7"}, "series2": {series2}})
+		if rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("series2 %q: got %d, want 422", series2, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"goplot/httplog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// prefix of Config.Address for listening on a Unix domain socket
+const unixAddressPrefix = "unix:"
+
+var errSocketInUse = errors.New("socket is in use by another server")
+
+// listens on Config.Address: host:port for TCP, or unix:/path/to.sock for
+// a Unix domain socket. A socket file left behind by a server that died is
+// removed first; one that still accepts connections is left alone.
+func listen(address string) (net.Listener, error) {
+	if !strings.HasPrefix(address, unixAddressPrefix) {
+		return net.Listen("tcp", address)
+	}
+	path := strings.TrimPrefix(address, unixAddressPrefix)
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errSocketInUse
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// how long a shutdown waits for requests in flight
+const shutdownTimeout = 30 * time.Second
+
+// requests being handled, for the shutdown log line
+var inFlight int64
+
+func countInFlight(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&inFlight, 1)
+		defer atomic.AddInt64(&inFlight, -1)
+		h.ServeHTTP(c, req)
+	})
+}
+
+// on SIGINT or SIGTERM stops the server from accepting connections, which
+// also removes the socket file of a Unix listener, and lets the requests in
+// flight finish. The returned channel is closed once they have.
+func shutdownOnSignal(server *http.Server, lifecycle lifecycleLog) <-chan struct{} {
+	stopped := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		lifecycle.printf("shutting down (%d in-flight)", atomic.LoadInt64(&inFlight))
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			lifecycle.printf("shutdown: %s", err.Error())
+		}
+		close(stopped)
+	}()
+	return stopped
+}
+
+// writes server lifecycle markers to the access log, or to stderr when
+// there is none. A marker takes the fields of the log format, the time for
+// TimeReceived and "-" for those of a request, followed by the message.
+type lifecycleLog struct {
+	logger *httplog.Logger
+	format []string
+}
+
+func (lifecycle lifecycleLog) printf(format string, args ...interface{}) {
+	tokens := lifecycle.format
+	if len(tokens) == 0 {
+		tokens = defaultLogFormat
+	}
+	fields := make([]string, len(tokens), len(tokens)+1)
+	for i, token := range tokens {
+		if token == "TimeReceived" {
+			fields[i] = time.Now().Format("[02/Jan/2006:15:04:05 -0700]")
+		} else {
+			fields[i] = "-"
+		}
+	}
+	fields = append(fields, "goplot: "+fmt.Sprintf(format, args...))
+	line := strings.Join(fields, " ") + "\n"
+	if lifecycle.logger != nil {
+		lifecycle.logger.Write([]byte(line))
+	} else {
+		os.Stderr.WriteString(line)
+	}
+}
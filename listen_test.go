@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"goplot/httplog"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestUnixSocketHealth(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goplot.sock")
+	// a socket file left behind by a server that died
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listen(unixAddressPrefix + path)
+	if err != nil {
+		t.Fatalf("over the stale socket: %v", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(healthzServer)}
+	go server.Serve(listener)
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		}}}
+	resp, err := client.Get("http://goplot/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ok\n" {
+		t.Errorf("got %d %q, want 200 ok", resp.StatusCode, body)
+	}
+
+	if _, err := listen(unixAddressPrefix + path); !errors.Is(err, errSocketInUse) {
+		t.Errorf("listening on a socket in use: got %v", err)
+	}
+	server.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the socket file is left after shutdown: %v", err)
+	}
+}
+
+func TestLifecycleLog(t *testing.T) {
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := free.Addr().String()
+	free.Close()
+	configPath := writeConfig(t, `{"CustomLog": "nolog", "Address": "`+address+`", "DataDir": "`+t.TempDir()+`"}`)
+	cmd := exec.Command(os.Args[0], "-c", configPath)
+	cmd.Env = append(os.Environ(), "GOPLOT_TEST_MAIN=1")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Process.Kill()
+
+	lines := bufio.NewScanner(stderr)
+	if !lines.Scan() || !strings.HasSuffix(lines.Text(), "goplot: listening on "+address) {
+		t.Fatalf("first line %q, want the listening marker", lines.Text())
+	}
+	// the signal handler is in place once requests are served
+	resp, err := http.Get("http://" + address + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+	var rest []string
+	for lines.Scan() {
+		rest = append(rest, lines.Text())
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("goplot exited with %v", err)
+	}
+	if len(rest) != 2 || !strings.HasSuffix(rest[0], "goplot: shutting down (0 in-flight)") || !strings.HasSuffix(rest[1], "goplot: stopped") {
+		t.Errorf("shutdown lines %q", rest)
+	}
+}
+
+func TestLifecycleLogFormat(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := httplog.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycleLog{logger, []string{"Status", "TimeReceived"}}.printf("listening on %s", ":7070")
+	lifecycleLog{logger, nil}.printf("stopped")
+	logger.Close()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %q, want two lines", lines)
+	}
+	if fields := strings.Fields(lines[0]); len(fields) != 7 || fields[0] != "-" || !strings.HasPrefix(fields[1], "[") || !strings.HasSuffix(lines[0], "goplot: listening on :7070") {
+		t.Errorf("custom format line %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "- - [") || !strings.HasSuffix(lines[1], "] - - - goplot: stopped") {
+		t.Errorf("default format line %q", lines[1])
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"goplot/compute"
+	"net/http"
+	"sync"
+)
+
+// passes each session's series on to the /goplot/live streams watching it
+type LiveFeed struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan []compute.Point]bool
+	closed      bool
+}
+
+// the feed of session appends, see SessionStore.Append
+var live = NewLiveFeed()
+
+func NewLiveFeed() *LiveFeed {
+	return &LiveFeed{subscribers: make(map[string]map[chan []compute.Point]bool)}
+}
+
+// a channel getting the session's series whenever it changes, and the
+// function ending the subscription. The channel holds only the latest
+// series, so a slow reader skips updates rather than holding up appends.
+// It is closed when the feed is.
+func (feed *LiveFeed) Subscribe(id string) (<-chan []compute.Point, func()) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	updates := make(chan []compute.Point, 1)
+	if feed.closed {
+		close(updates)
+		return updates, func() {}
+	}
+	if feed.subscribers[id] == nil {
+		feed.subscribers[id] = make(map[chan []compute.Point]bool)
+	}
+	feed.subscribers[id][updates] = true
+	return updates, func() {
+		feed.mu.Lock()
+		defer feed.mu.Unlock()
+		if !feed.subscribers[id][updates] {
+			return
+		}
+		delete(feed.subscribers[id], updates)
+		if len(feed.subscribers[id]) == 0 {
+			delete(feed.subscribers, id)
+		}
+	}
+}
+
+// sends the session's series to its subscribers
+func (feed *LiveFeed) Publish(id string, series []compute.Point) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	for updates := range feed.subscribers[id] {
+		// replace an update that hasn't been read yet
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- series
+	}
+}
+
+// ends every subscription, for server shutdown
+func (feed *LiveFeed) Close() {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+	feed.closed = true
+	for id, subscribers := range feed.subscribers {
+		for updates := range subscribers {
+			close(updates)
+		}
+		delete(feed.subscribers, id)
+	}
+}
+
+// streams the fit of the session's series as Server-Sent Events, one
+// "regression" event now if the session has points and one each time
+// points are added, or appends points to the session:
+// GET /goplot/live
+// POST /goplot/live with dataseries, as /goplot/viz?session=true would
+func liveServer(c http.ResponseWriter, req *http.Request) {
+	id, err := sessionID(c, req)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	switch req.Method {
+	case "GET":
+		streamLive(c, req, id)
+	case "POST":
+		points, err := compute.ParseSeries(req.FormValue("dataseries"))
+		if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		if _, err = sessions.Append(id, points); err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		c.WriteHeader(http.StatusNoContent)
+	default:
+		serveError(c, req, http.StatusMethodNotAllowed)
+	}
+}
+
+func streamLive(c http.ResponseWriter, req *http.Request, id string) {
+	flusher, ok := c.(http.Flusher)
+	if !ok {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	updates, unsubscribe := live.Subscribe(id)
+	defer unsubscribe()
+
+	c.Header().Set("Content-Type", "text/event-stream")
+	c.Header().Set("Cache-Control", "no-cache")
+	c.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	send := func(series []compute.Point) {
+		line := compute.FitLine(series)
+		data, err := json.Marshal(struct {
+			compute.Envelope
+			compute.RegressionLine
+			N int `json:"n"`
+		}{compute.NewEnvelope(), line, len(series)})
+		// too few points for a line gives NaN, which JSON can't hold; the
+		// next append may do
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(c, "event: regression\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+	if series := sessions.Series(id); len(series) > 0 {
+		send(series)
+	}
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case series, ok := <-updates:
+			if !ok {
+				return
+			}
+			send(series)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the data of the next "regression" event in the stream
+func nextRegression(t *testing.T, events *bufio.Scanner) (n int, slope float64) {
+	t.Helper()
+	event := ""
+	for events.Scan() {
+		line := events.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		} else if strings.HasPrefix(line, "data: ") && event == "regression" {
+			var fit struct {
+				N     int
+				Slope float64
+			}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &fit); err != nil {
+				t.Fatal(err)
+			}
+			return fit.N, fit.Slope
+		}
+	}
+	t.Fatalf("stream ended: %v", events.Err())
+	return 0, 0
+}
+
+func TestLiveEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(liveServer))
+	defer server.Close()
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	post := func(data string) {
+		resp, err := client.PostForm(server.URL+"/goplot/live", url.Values{"dataseries": {data}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("appending got %d", resp.StatusCode)
+		}
+	}
+
+	post("1,2\n2,4\n3,6")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/goplot/live", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type %q", got)
+	}
+	events := bufio.NewScanner(resp.Body)
+	if n, slope := nextRegression(t, events); n != 3 || slope != 2 {
+		t.Errorf("first event: %d points, slope %v, want 3 and 2", n, slope)
+	}
+	post("4,9\n5,12")
+	if n, _ := nextRegression(t, events); n != 5 {
+		t.Errorf("after appending: %d points, want 5", n)
+	}
+
+	// the subscription goes with the client
+	cancel()
+	deadline := time.Now().Add(time.Second)
+	for {
+		live.mu.Lock()
+		subscribed := len(live.subscribers)
+		live.mu.Unlock()
+		if subscribed == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions still subscribed after the client left", subscribed)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
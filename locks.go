@@ -0,0 +1,53 @@
+package main
+
+import (
+	"sync"
+)
+
+// per series read/write locks, so concurrent requests on the same named
+// series don't lose each other's changes
+type SeriesLockManager struct {
+	mu    sync.Mutex
+	locks map[string]*sync.RWMutex
+}
+
+// the lock manager of the series in Config.DataDir, set up by main
+var seriesLocks *SeriesLockManager
+
+func NewSeriesLockManager() *SeriesLockManager {
+	return &SeriesLockManager{locks: make(map[string]*sync.RWMutex)}
+}
+
+// the lock of the named series, created on first use
+func (m *SeriesLockManager) Lock(name string) *sync.RWMutex {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	lock, ok := m.locks[name]
+	if !ok {
+		lock = &sync.RWMutex{}
+		m.locks[name] = lock
+	}
+	return lock
+}
+
+// read locks src and write locks dst, in name order so two opposite copies
+// can't deadlock. Returns the function undoing both.
+func (m *SeriesLockManager) LockCopy(src, dst string) (unlock func()) {
+	if src == dst {
+		lock := m.Lock(src)
+		lock.Lock()
+		return lock.Unlock
+	}
+	srcLock, dstLock := m.Lock(src), m.Lock(dst)
+	if src < dst {
+		srcLock.RLock()
+		dstLock.Lock()
+	} else {
+		dstLock.Lock()
+		srcLock.RLock()
+	}
+	return func() {
+		dstLock.Unlock()
+		srcLock.RUnlock()
+	}
+}
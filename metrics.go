@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"math/bits"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// request latency per registered pattern, published as expvar "latency"
+var latencies = expvar.NewMap("latency")
+
+// the same histograms, for resetting
+var latencyHistograms sync.Map
+
+// approximate latency histogram with power-of-two buckets: bucket i counts
+// durations of i significant bits in nanoseconds, so recording is a few
+// atomic adds and percentiles come within a factor of two
+type latencyHistogram struct {
+	buckets [65]uint64
+	count   uint64
+	max     uint64
+}
+
+func (h *latencyHistogram) record(d time.Duration) {
+	ns := uint64(d)
+	if d < 0 {
+		ns = 0
+	}
+	atomic.AddUint64(&h.buckets[bits.Len64(ns)], 1)
+	atomic.AddUint64(&h.count, 1)
+	for {
+		max := atomic.LoadUint64(&h.max)
+		if ns <= max || atomic.CompareAndSwapUint64(&h.max, max, ns) {
+			break
+		}
+	}
+}
+
+// the upper bound of the bucket holding the p-th quantile, capped at the max
+func (h *latencyHistogram) percentile(p float64) time.Duration {
+	count := atomic.LoadUint64(&h.count)
+	if count == 0 {
+		return 0
+	}
+	rank := uint64(p*float64(count) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	seen := uint64(0)
+	max := atomic.LoadUint64(&h.max)
+	for i := range h.buckets {
+		seen += atomic.LoadUint64(&h.buckets[i])
+		if seen >= rank {
+			upper := uint64(1)<<uint(i) - 1
+			if i == 64 || upper > max {
+				upper = max
+			}
+			return time.Duration(upper)
+		}
+	}
+	return time.Duration(max)
+}
+
+func (h *latencyHistogram) reset() {
+	for i := range h.buckets {
+		atomic.StoreUint64(&h.buckets[i], 0)
+	}
+	atomic.StoreUint64(&h.count, 0)
+	atomic.StoreUint64(&h.max, 0)
+}
+
+// expvar.Var, in milliseconds
+func (h *latencyHistogram) String() string {
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	data, _ := json.Marshal(map[string]float64{
+		"p50": ms(h.percentile(0.50)),
+		"p95": ms(h.percentile(0.95)),
+		"p99": ms(h.percentile(0.99)),
+		"max": ms(time.Duration(atomic.LoadUint64(&h.max)))})
+	return string(data)
+}
+
+// records how long each request takes under the mux pattern it matched,
+// and adds it to the request analytics
+func recordLatency(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		var body *countingBody
+		if req.Method == "POST" && req.Body != nil {
+			body = &countingBody{ReadCloser: req.Body}
+			req.Body = body
+		}
+		mux.ServeHTTP(c, req)
+		_, pattern := mux.Handler(req)
+		if pattern == "" {
+			return
+		}
+		record := requestRecord{at: start, bodyBytes: -1, duration: time.Since(start), endpoint: pattern}
+		if body != nil {
+			record.bodyBytes = body.n
+		}
+		analytics.Record(record)
+		h, ok := latencyHistograms.Load(pattern)
+		if !ok {
+			var loaded bool
+			if h, loaded = latencyHistograms.LoadOrStore(pattern, &latencyHistogram{}); !loaded {
+				latencies.Set(pattern, h.(*latencyHistogram))
+			}
+		}
+		h.(*latencyHistogram).record(record.duration)
+	})
+}
+
+// clears the latency histograms
+// POST /admin/reset-metrics with the X-Admin-Secret header
+func resetMetricsServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	if !adminAllowed(req) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	latencyHistograms.Range(func(_, h interface{}) bool {
+		h.(*latencyHistogram).reset()
+		return true
+	})
+	c.WriteHeader(http.StatusNoContent)
+}
+
+// checks the X-Admin-Secret header; admin endpoints are off without
+// Config.AdminSecret
+func adminAllowed(req *http.Request) bool {
+	secret := req.Header.Get("X-Admin-Secret")
+	return config.AdminSecret != "" &&
+		subtle.ConstantTimeCompare([]byte(secret), []byte(config.AdminSecret)) == 1
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	h := &latencyHistogram{}
+	for i := 1; i <= 100; i++ {
+		h.record(time.Duration(i) * time.Millisecond)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		// 50ms is in the bucket of 26-bit nanosecond counts
+		{0.50, 1<<26 - 1},
+		// 2^27-1 ns is past the largest duration
+		{0.95, 100 * time.Millisecond},
+		{0.99, 100 * time.Millisecond},
+		// 1ms, in the 20-bit bucket
+		{0, 1<<20 - 1},
+	}
+	for _, test := range tests {
+		if got := h.percentile(test.p); got != test.want {
+			t.Errorf("p%v: got %v, want %v", test.p*100, got, test.want)
+		}
+	}
+
+	var published map[string]float64
+	if err := json.Unmarshal([]byte(h.String()), &published); err != nil {
+		t.Fatal(err)
+	}
+	if published["max"] != 100 || published["p99"] != 100 || published["p50"] != float64(1<<26-1)/1e6 {
+		t.Errorf("published %v", published)
+	}
+}
+
+func TestResetMetrics(t *testing.T) {
+	h := &latencyHistogram{}
+	h.record(time.Second)
+	latencyHistograms.Store("/test/reset", h)
+	defer latencyHistograms.Delete("/test/reset")
+	saved := config.AdminSecret
+	defer func() { config.AdminSecret = saved }()
+	config.AdminSecret = "s3cret"
+
+	for secret, want := range map[string]int{"": http.StatusForbidden, "wrong": http.StatusForbidden, "s3cret": http.StatusNoContent} {
+		req := httptest.NewRequest("POST", "/admin/reset-metrics", nil)
+		req.Header.Set("X-Admin-Secret", secret)
+		rec := httptest.NewRecorder()
+		resetMetricsServer(rec, req)
+		if rec.Code != want {
+			t.Errorf("secret %q: got %d, want %d", secret, rec.Code, want)
+		}
+	}
+	if h.percentile(0.5) != 0 || h.count != 0 {
+		t.Errorf("not reset: %d durations, p50 %v", h.count, h.percentile(0.5))
+	}
+}
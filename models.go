@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+// fit models by name, picked with the method form field of /goplot/viz and
+// listed at /goplot/models. A model gets the points and the request form
+// and returns the fitted *compute.RegressionLine, or a
+// *compute.RegressionError when the points can't be fitted.
+var fitModels = map[string]func(series []compute.Point, params url.Values) (interface{}, error){
+	"ols": func(series []compute.Point, params url.Values) (interface{}, error) {
+		if err := compute.CheckFittable("linear", series); err != nil {
+			return nil, err
+		}
+		line := compute.FitLine(series)
+		line.ZeroUndefinedCorrelation()
+		return &line, nil
+	},
+	"lad": func(series []compute.Point, params url.Values) (interface{}, error) {
+		if err := compute.CheckFittable("lad", series); err != nil {
+			return nil, err
+		}
+		line := compute.LADRegression(series, config.MaxLADIterations)
+		line.ZeroUndefinedCorrelation()
+		return &line, nil
+	},
+}
+
+var errNotALine = errors.New("fit model didn't return a regression line")
+
+// fits the series with the named model from fitModels
+func fitModel(name string, series []compute.Point, params url.Values) (*compute.RegressionLine, error) {
+	fit, ok := fitModels[name]
+	if !ok {
+		return nil, errUnknownMethod
+	}
+	result, err := fit(series, params)
+	if err != nil {
+		return nil, err
+	}
+	line, ok := result.(*compute.RegressionLine)
+	if !ok {
+		return nil, errNotALine
+	}
+	return line, nil
+}
+
+// lists the fit models
+// GET /goplot/models
+func modelsServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	catalog := struct {
+		compute.Envelope
+		Models []string `json:"models"`
+	}{Envelope: compute.NewEnvelope(), Models: make([]string, 0, len(fitModels))}
+	for name := range fitModels {
+		catalog.Models = append(catalog.Models, name)
+	}
+	sort.Strings(catalog.Models)
+
+	jsonCatalog, err := json.Marshal(catalog)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonCatalog)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestFitModelRegistry(t *testing.T) {
+	// a horizontal line at the level form field
+	fitModels["dummy"] = func(series []compute.Point, params url.Values) (interface{}, error) {
+		level, err := strconv.ParseFloat(params.Get("level"), 64)
+		return &compute.RegressionLine{Intercept: level}, err
+	}
+	defer delete(fitModels, "dummy")
+
+	dataSample := postViz(t, url.Values{"dataseries": {"1,2\n2,4\n3,7"}, "method": {"dummy"}, "level": {"42"}})
+	if line := dataSample.RegressionLine; line.Slope != 0 || line.Intercept != 42 {
+		t.Errorf("got y = %vx + %v, want the dummy's y = 42", line.Slope, line.Intercept)
+	}
+
+	rec := httptest.NewRecorder()
+	modelsServer(rec, httptest.NewRequest("GET", "/goplot/models", nil))
+	var catalog struct{ Models []string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &catalog); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dummy", "lad", "ols"}; !reflect.DeepEqual(catalog.Models, want) {
+		t.Errorf("catalog %q, want %q", catalog.Models, want)
+	}
+
+	// a model returning something else than a line
+	fitModels["dummy"] = func(series []compute.Point, params url.Values) (interface{}, error) { return 42, nil }
+	if _, err := fitModel("dummy", nil, nil); err != errNotALine {
+		t.Errorf("got %v, want %v", err, errNotALine)
+	}
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7"}, "method": {"none"}}
+	if rec := postForm(dataSampleServer, "/goplot/viz", form); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown method: got %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestFitModelsDegenerate(t *testing.T) {
+	for _, method := range []string{"ols", "lad"} {
+		// a flat line is fitted, with no correlation to speak of
+		line := postViz(t, url.Values{"dataseries": {"1,5\n2,5\n3,5\n4,5"}, "method": {method}}).RegressionLine
+		if math.Abs(line.Slope) > 1e-9 || math.Abs(line.Intercept-5) > 1e-9 || line.Correlation != 0 {
+			t.Errorf("%s flat: got %+v", method, line)
+		}
+		// the least squares line is flat exactly, and can't cross y=0
+		if method == "ols" && !line.XInterceptUndefined {
+			t.Errorf("ols flat: crosses y=0 at %v", line.XIntercept)
+		}
+		for _, data := range []string{"", "1,2\n2,4", "1,2\n1,4\n1,7"} {
+			for _, format := range []string{"json", "plain"} {
+				form := url.Values{"dataseries": {data}, "method": {method}}
+				if rec := postForm(dataSampleServer, "/goplot/viz?format="+format, form); rec.Code != http.StatusUnprocessableEntity {
+					t.Errorf("%s %q as %s: got %d %s, want %d", method, data, format, rec.Code, rec.Body, http.StatusUnprocessableEntity)
+				}
+			}
+		}
+	}
+}
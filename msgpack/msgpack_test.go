@@ -0,0 +1,204 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"goplot/compute"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// decodes the MessagePack written by Marshal into the types encoding/json
+// decodes into an interface{}: maps, slices, float64, string, bool and nil
+func decode(r *bytes.Reader) (interface{}, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length := func(size int) (n int, err error) {
+		switch size {
+		case 1:
+			var v uint8
+			err = binary.Read(r, binary.BigEndian, &v)
+			n = int(v)
+		case 2:
+			var v uint16
+			err = binary.Read(r, binary.BigEndian, &v)
+			n = int(v)
+		default:
+			var v uint32
+			err = binary.Read(r, binary.BigEndian, &v)
+			n = int(v)
+		}
+		return n, err
+	}
+	number := func(v interface{}) (interface{}, error) {
+		err := binary.Read(r, binary.BigEndian, v)
+		return reflect.ValueOf(v).Elem().Convert(reflect.TypeOf(0.0)).Interface(), err
+	}
+	switch {
+	case code <= 0x7f:
+		return float64(code), nil
+	case code >= 0xe0:
+		return float64(int8(code)), nil
+	case code&0xf0 == 0x80:
+		return decodeMap(r, int(code&0x0f))
+	case code&0xf0 == 0x90:
+		return decodeArray(r, int(code&0x0f))
+	case code&0xe0 == 0xa0:
+		return decodeString(r, int(code&0x1f))
+	}
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xca:
+		var bits uint32
+		err := binary.Read(r, binary.BigEndian, &bits)
+		return float64(math.Float32frombits(bits)), err
+	case 0xcb:
+		var bits uint64
+		err := binary.Read(r, binary.BigEndian, &bits)
+		return math.Float64frombits(bits), err
+	case 0xcc:
+		return number(new(uint8))
+	case 0xcd:
+		return number(new(uint16))
+	case 0xce:
+		return number(new(uint32))
+	case 0xcf:
+		return number(new(uint64))
+	case 0xd0:
+		return number(new(int8))
+	case 0xd1:
+		return number(new(int16))
+	case 0xd2:
+		return number(new(int32))
+	case 0xd3:
+		return number(new(int64))
+	case 0xd9, 0xda, 0xdb:
+		n, err := length(map[byte]int{0xd9: 1, 0xda: 2, 0xdb: 4}[code])
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, n)
+	case 0xdc, 0xdd:
+		n, err := length(map[byte]int{0xdc: 2, 0xdd: 4}[code])
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, n)
+	case 0xde, 0xdf:
+		n, err := length(map[byte]int{0xde: 2, 0xdf: 4}[code])
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, n)
+	}
+	return nil, fmt.Errorf("unexpected code %#x", code)
+}
+
+func decodeString(r *bytes.Reader, n int) (interface{}, error) {
+	s := make([]byte, n)
+	_, err := io.ReadFull(r, s)
+	return string(s), err
+}
+
+func decodeArray(r *bytes.Reader, n int) (interface{}, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		var err error
+		if a[i], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func decodeMap(r *bytes.Reader, n int) (interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		if m[key.(string)], err = decode(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// v through MessagePack and through JSON must come back the same
+func checkRoundTrip(t *testing.T, v interface{}) {
+	t.Helper()
+	packed, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(packed)
+	fromMsgpack, err := decode(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Len() > 0 {
+		t.Errorf("%d bytes left over", r.Len())
+	}
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON interface{}
+	if err := json.Unmarshal(jsonBytes, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromMsgpack, fromJSON) {
+		t.Errorf("MessagePack gave %v\nJSON gave %v", fromMsgpack, fromJSON)
+	}
+}
+
+func TestDataSampleRoundTrip(t *testing.T) {
+	series := make([]compute.Point, 20)
+	for i := range series {
+		series[i] = compute.Point{X: float64(i) - 5.5, Y: 2.25*float64(i) + 1}
+	}
+	series[3].YErr = 0.5
+	ds := compute.NewDataSample(series, compute.Metadata{XLabel: "time", YUnit: "m"})
+	ds.ColumnNames = []string{"t", "distance"}
+	ds.SkippedLines = 2
+	ds.HeuristicScores = map[string]float64{"linear": 1, "powerlaw": 0.5}
+	checkRoundTrip(t, ds)
+}
+
+func TestEncodingSizes(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+	}
+	type tagged struct {
+		inner
+		Name    string            `json:"name"`
+		Skipped string            `json:"-"`
+		Empty   []int             `json:"empty,omitempty"`
+		Plain   int64             // keyed by the field name
+		Ints    []int64           `json:"ints"`
+		Long    string            `json:"long"`
+		Longer  string            `json:"longer"`
+		Many    []bool            `json:"many"`
+		Map     map[string]string `json:"map"`
+		Nil     *inner            `json:"nil"`
+	}
+	checkRoundTrip(t, tagged{inner: inner{A: -7}, Name: "x", Skipped: "y", Plain: 1 << 40,
+		Ints:   []int64{0, 127, 128, 255, 256, 65536, -1, -32, -33, -129, -32769, -1 << 40},
+		Long:   strings.Repeat("a", 40),
+		Longer: strings.Repeat("b", 300),
+		Many:   make([]bool, 70000),
+		Map:    map[string]string{"k": "v", "j": ""}})
+}
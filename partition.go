@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// splits a stored series at the given X values and sends back the fit of
+// each part, optionally saving the parts as {series}_part0, {series}_part1, ...
+// POST /goplot/partition?series=name&splitAt=100,200[&save=true]
+func partitionServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	name := req.FormValue("series")
+	if validSeriesName(name) != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	apiKey := req.Header.Get("X-API-Key")
+	if !seriesAllowed(apiKey, name) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	splits := make([]float64, 0)
+	for _, field := range strings.Split(req.FormValue("splitAt"), ",") {
+		split, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+		splits = append(splits, split)
+	}
+	save, _ := strconv.ParseBool(req.FormValue("save"))
+	partNames := make([]string, len(splits)+1)
+	for i := range partNames {
+		partNames[i] = name + "_part" + strconv.Itoa(i)
+		if save && (validSeriesName(partNames[i]) != nil || !seriesAllowed(apiKey, partNames[i])) {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+	}
+
+	lock := seriesLocks.Lock(name)
+	lock.RLock()
+	exists := seriesExists(name)
+	stored, err := loadSeries(name)
+	lock.RUnlock()
+	if !exists {
+		serveError(c, req, http.StatusNotFound)
+		return
+	} else if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	parts, err := compute.PartitionByX(stored.Series, splits)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	metadata := metadataFromRequest(req)
+	samples := make([]*compute.DataSample, len(parts))
+	for i, part := range parts {
+		if save {
+			lock := seriesLocks.Lock(partNames[i])
+			lock.Lock()
+			err = saveSeries(partNames[i], &StoredSeries{Series: part})
+			lock.Unlock()
+			if err != nil {
+				serveErrorFor(c, req, err)
+				return
+			}
+		}
+		// a part too small to fit gets no line
+		samples[i] = &compute.DataSample{Series: part,
+			Envelope:       compute.NewEnvelope(),
+			RegressionLine: seriesFit(part),
+			Metadata:       metadata}
+	}
+
+	jsonSamples, err := json.Marshal(samples)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSamples)
+}
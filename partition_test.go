@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"testing"
+)
+
+func TestPartitionServer(t *testing.T) {
+	storeSeries(t, "long", "1,1\n2,2\n3,3\n10,20\n11,22\n12,24\n13,26\n20,5")
+	rec := postForm(partitionServer, "/goplot/partition?series=long&splitAt=10,20&save=true", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var samples []compute.DataSample
+	if err := json.Unmarshal(rec.Body.Bytes(), &samples); err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 || len(samples[0].Series) != 3 || len(samples[1].Series) != 4 || len(samples[2].Series) != 1 {
+		t.Fatalf("got %d parts: %v", len(samples), samples)
+	}
+	if line := samples[1].RegressionLine; line == nil || line.Slope != 2 {
+		t.Errorf("middle part fit %v, want slope 2", line)
+	}
+	// one point can't be fitted
+	if samples[2].RegressionLine != nil {
+		t.Errorf("last part fit %v", samples[2].RegressionLine)
+	}
+	for i, want := range []int{3, 4, 1} {
+		part, err := loadSeries("long_part" + string(rune('0'+i)))
+		if err != nil || len(part.Series) != want {
+			t.Errorf("saved part %d: %d points, %v; want %d", i, len(part.Series), err, want)
+		}
+	}
+
+	for target, want := range map[string]int{
+		"/goplot/partition?series=long&splitAt=20,10":   http.StatusBadRequest,
+		"/goplot/partition?series=long&splitAt=ten":     http.StatusBadRequest,
+		"/goplot/partition?series=missing&splitAt=10":   http.StatusNotFound,
+		"/goplot/partition?series=../long&splitAt=10,2": http.StatusBadRequest,
+	} {
+		if rec := postForm(partitionServer, target, nil); rec.Code != want {
+			t.Errorf("%s: got %d, want %d", target, rec.Code, want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"goplot/compute"
+	"io"
+	"math"
+	"net/http"
+)
+
+// most operations a pipeline may chain
+const maxPipeStages = 20
+
+var errTooManyStages = errors.New("at most 20 operations can be chained")
+var errUnknownOp = errors.New("unknown op, not parse, filter, regress or predict")
+var errNeedsSeries = errors.New("needs a data series, from parse or filter")
+var errNeedsLine = errors.New("needs a fitted line, from regress")
+var errNoPredictX = errors.New("x must be given")
+
+// a step of a pipeline, turning the previous step's output into its own
+type PipelineStage interface {
+	Execute(input interface{}) (interface{}, error)
+}
+
+// an operation as posted to /goplot/pipe; which fields count depends on Op
+type PipeOp struct {
+	Op   string   `json:"op"`
+	Data string   `json:"data"`
+	Expr string   `json:"expr"`
+	Type string   `json:"type"`
+	X    *float64 `json:"x"`
+}
+
+// parses Data into a data series, ignoring its input
+type parseStage struct{ data string }
+
+func (stage parseStage) Execute(input interface{}) (interface{}, error) {
+	return compute.ParseSeries(stage.data)
+}
+
+// keeps the points of a series that match the filter
+type filterStage struct{ keep func(compute.Point) bool }
+
+func (stage filterStage) Execute(input interface{}) (interface{}, error) {
+	series, ok := input.([]compute.Point)
+	if !ok {
+		return nil, errNeedsSeries
+	}
+	return compute.FilterPoints(series, stage.keep), nil
+}
+
+// fits a series with one of compute.ShapeModels
+type regressStage struct{ model string }
+
+func (stage regressStage) Execute(input interface{}) (interface{}, error) {
+	series, ok := input.([]compute.Point)
+	if !ok {
+		return nil, errNeedsSeries
+	}
+	// FitShape leaves these as NaN, which JSON can't carry
+	if err := compute.CheckFittable(stage.model, series); err != nil {
+		return nil, err
+	}
+	line, err := compute.FitShape(series, stage.model)
+	if err != nil {
+		return nil, err
+	}
+	// the correlation is undefined, and the model can't be judged
+	if math.IsNaN(line.Correlation) {
+		return nil, &compute.RegressionError{Model: stage.model, Err: compute.ErrSameY}
+	}
+	return &line, nil
+}
+
+// the fitted value of a line at x
+type predictStage struct{ x float64 }
+
+type pipePrediction struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+func (stage predictStage) Execute(input interface{}) (interface{}, error) {
+	line, ok := input.(*compute.RegressionLine)
+	if !ok {
+		return nil, errNeedsLine
+	}
+	return pipePrediction{X: stage.x, Y: line.ValueAt(stage.x)}, nil
+}
+
+// the stage carrying out an operation
+func newPipelineStage(op PipeOp) (PipelineStage, error) {
+	switch op.Op {
+	case "parse":
+		return parseStage{op.Data}, nil
+	case "filter":
+		keep, err := compute.ParseFilter(op.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return filterStage{keep}, nil
+	case "regress":
+		if op.Type == "" {
+			op.Type = "linear"
+		}
+		return regressStage{op.Type}, nil
+	case "predict":
+		if op.X == nil {
+			return nil, errNoPredictX
+		}
+		return predictStage{*op.X}, nil
+	}
+	return nil, errUnknownOp
+}
+
+// runs the stages in order, each on the previous one's output, returning
+// every output
+func runPipeline(stages []PipelineStage) ([]interface{}, error) {
+	outputs := make([]interface{}, 0, len(stages))
+	var output interface{}
+	for i, stage := range stages {
+		var err error
+		if output, err = stage.Execute(output); err != nil {
+			return nil, pipeStageError(i, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
+
+// names the failed stage in errors about the request; fits that can't be
+// made are reported as they are
+func pipeStageError(i int, err error) error {
+	var regressionError *compute.RegressionError
+	if errors.As(err, &regressionError) {
+		return err
+	}
+	return &compute.ParseError{Err: fmt.Errorf("operation %d: %w", i+1, err)}
+}
+
+// chains operations in one request, each taking the output of the one
+// before, and returns the output of every one
+// POST /goplot/pipe [{"op": "parse", "data": "1,2\n..."}, {"op": "filter", "expr": "y>0"}, {"op": "regress"}, {"op": "predict", "x": 5}]
+func pipeServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	var ops []PipeOp
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxUploadBytes)).Decode(&ops); err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if len(ops) > maxPipeStages {
+		serveErrorFor(c, req, &compute.ParseError{Err: errTooManyStages})
+		return
+	}
+	stages := make([]PipelineStage, len(ops))
+	for i, op := range ops {
+		var err error
+		if stages[i], err = newPipelineStage(op); err != nil {
+			serveErrorFor(c, req, pipeStageError(i, err))
+			return
+		}
+	}
+	outputs, err := runPipeline(stages)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonOutputs, err := json.Marshal(struct {
+		compute.Envelope
+		Outputs []interface{} `json:"outputs"`
+	}{compute.NewEnvelope(), outputs})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonOutputs)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// posts the operations to the pipe endpoint
+func postPipe(body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	pipeServer(rec, httptest.NewRequest("POST", "/goplot/pipe", strings.NewReader(body)))
+	return rec
+}
+
+func TestPipeMatchesSeparateRequests(t *testing.T) {
+	rec := postPipe(`[{"op": "parse", "data": "1,2\n2,-1\n3,7\n4,8\n5,-3\n6,13"},
+		{"op": "filter", "expr": "y>0"},
+		{"op": "regress", "type": "linear"}]`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var result struct{ Outputs []json.RawMessage }
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	outputs := result.Outputs
+	if len(outputs) != 3 {
+		t.Fatalf("got %d outputs, want 3", len(outputs))
+	}
+	var filtered []compute.Point
+	var line compute.RegressionLine
+	if err := json.Unmarshal(outputs[1], &filtered); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(outputs[2], &line); err != nil {
+		t.Fatal(err)
+	}
+
+	// the points kept are the ones /goplot/viz gets on its own
+	dataSample := postViz(t, url.Values{"dataseries": {"1,2\n3,7\n4,8\n6,13"}})
+	if !reflect.DeepEqual(filtered, dataSample.Series) {
+		t.Errorf("filtered %v, want %v", filtered, dataSample.Series)
+	}
+	if !reflect.DeepEqual(line, *dataSample.RegressionLine) {
+		t.Errorf("pipe fit %+v, want %+v", line, *dataSample.RegressionLine)
+	}
+}
+
+func TestPipePredict(t *testing.T) {
+	rec := postPipe(`[{"op": "parse", "data": "0,1\n1,3\n2,5"}, {"op": "regress"}, {"op": "predict", "x": 10}]`)
+	var result struct{ Outputs []json.RawMessage }
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || len(result.Outputs) != 3 {
+		t.Fatalf("got %d %s", rec.Code, rec.Body)
+	}
+	outputs := result.Outputs
+	var prediction pipePrediction
+	json.Unmarshal(outputs[2], &prediction)
+	if prediction != (pipePrediction{X: 10, Y: 21}) {
+		t.Errorf("got %+v, want y(10) = 21", prediction)
+	}
+}
+
+func TestPipeErrors(t *testing.T) {
+	tests := []struct {
+		body string
+		want int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`[{"op": "sort"}]`, http.StatusBadRequest},
+		{`[{"op": "filter", "expr": "z>0"}]`, http.StatusBadRequest},
+		{`[{"op": "predict"}]`, http.StatusBadRequest},
+		// a stage given the wrong kind of input
+		{`[{"op": "regress"}]`, http.StatusBadRequest},
+		{`[{"op": "parse", "data": "0,1\n1,3\n2,5"}, {"op": "predict", "x": 1}]`, http.StatusBadRequest},
+		{"[" + strings.Repeat(`{"op": "regress"},`, maxPipeStages) + `{"op": "regress"}]`, http.StatusBadRequest},
+		{`[{"op": "parse", "data": "1,1\n1,2\n1,3"}, {"op": "regress"}]`, http.StatusUnprocessableEntity},
+		{`[{"op": "parse", "data": "1,1\n2,2"}, {"op": "regress"}]`, http.StatusUnprocessableEntity},
+		{`[{"op": "parse", "data": "1,5\n2,5\n3,5\n4,5"}, {"op": "regress"}]`, http.StatusUnprocessableEntity},
+		{`[{"op": "parse", "data": "1,5\n2,5\n3,5\n4,5"}, {"op": "regress", "type": "exponential"}]`, http.StatusUnprocessableEntity},
+		{`[{"op": "parse", "data": "1,5\n2,5\n3,5\n4,5"}, {"op": "regress", "type": "logarithmic"}]`, http.StatusUnprocessableEntity},
+	}
+	for _, test := range tests {
+		if rec := postPipe(test.body); rec.Code != test.want {
+			t.Errorf("%s: got %d, want %d", test.body, rec.Code, test.want)
+		}
+	}
+}
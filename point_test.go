@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPointString(t *testing.T) {
+	pt := &Point{X: 1, Y: 2.5}
+	var decoded struct{ X, Y float64 }
+	if err := json.Unmarshal([]byte(pt.String()), &decoded); err != nil || decoded.X != 1 || decoded.Y != 2.5 {
+		t.Errorf("String() = %q, want JSON of x 1 and y 2.5", pt.String())
+	}
+	pt.X = math.NaN()
+	if got := pt.String(); got != "null" {
+		t.Errorf("with NaN got %q, want null", got)
+	}
+
+	// expvar reads the point while requests change it; run with -race
+	pt = &Point{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			pt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/point", nil))
+		}()
+		go func() {
+			defer wg.Done()
+			if !json.Valid([]byte(pt.String())) {
+				t.Error("String() isn't JSON")
+			}
+		}()
+	}
+	wg.Wait()
+	if pt.X != 10 {
+		t.Errorf("x = %v after 10 GETs", pt.X)
+	}
+}
+
+func TestPointPost(t *testing.T) {
+	tests := []struct {
+		x, y       string
+		wantCode   int
+		wantFields []string
+	}{
+		{"1.5", "-2", http.StatusOK, nil},
+		{"abc", "2", http.StatusBadRequest, []string{"x"}},
+		{"1", "xyz", http.StatusBadRequest, []string{"y"}},
+		{"abc", "xyz", http.StatusBadRequest, []string{"x", "y"}},
+	}
+	for _, test := range tests {
+		pt := &Point{X: 7, Y: 7}
+		rec := postForm(pt.ServeHTTP, "/point", url.Values{"x": {test.x}, "y": {test.y}})
+		if rec.Code != test.wantCode {
+			t.Errorf("x=%s&y=%s: got %d, want %d", test.x, test.y, rec.Code, test.wantCode)
+			continue
+		}
+		if test.wantCode == http.StatusOK {
+			if pt.X != 1.5 || pt.Y != -2 {
+				t.Errorf("point is (%v,%v), want (1.5,-2)", pt.X, pt.Y)
+			}
+			continue
+		}
+		var answer struct {
+			Error  string
+			Fields []string
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &answer); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(answer.Fields, test.wantFields) || !strings.HasSuffix(answer.Error, strings.Join(test.wantFields, ", ")) {
+			t.Errorf("x=%s&y=%s: got %+v, want fields %q", test.x, test.y, answer, test.wantFields)
+		}
+		if pt.X != 7 || pt.Y != 7 {
+			t.Errorf("x=%s&y=%s: point changed to (%v,%v)", test.x, test.y, pt.X, pt.Y)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// how many points are needed to detect a slope of the given effect size
+// GET /goplot/power?effectSize=0.15&alpha=0.05&power=0.8
+func powerServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	effectSize, err := floatParam(req, "effectSize", 0)
+	if err != nil || effectSize <= 0 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	alpha, err := floatParam(req, "alpha", 0.05)
+	if err != nil || alpha <= 0 || alpha >= 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	power, err := floatParam(req, "power", 0.8)
+	if err != nil || power <= alpha || power >= 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	sampleSize, err := compute.RequiredSampleSize(effectSize, alpha, power)
+	if err != nil {
+		serveError(c, req, http.StatusUnprocessableEntity)
+		return
+	}
+
+	jsonSampleSize, err := json.Marshal(struct {
+		compute.Envelope
+		compute.SampleSize
+	}{compute.NewEnvelope(), sampleSize})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSampleSize)
+}
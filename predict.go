@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// predictions from the least squares line, with their prediction intervals
+type predictions struct {
+	compute.Envelope
+	Level       float64              `json:"level"`
+	Predictions []compute.Prediction `json:"predictions"`
+}
+
+var errTooManyPredictions = errors.New("more x values than MaxPredictions")
+var errNoPredictionXs = errors.New("x, or xStart, xEnd and xStep, must be given")
+var errMixedXs = errors.New("give x or an xStart, xEnd and xStep range, not both")
+var errBadXRange = errors.New("xStart, xEnd and xStep must be numbers with xStep > 0 and xEnd >= xStart")
+
+// predicts y from the posted data series at the x values, given comma
+// separated, as repeated x fields or as a range from xStart to xEnd by
+// xStep. At most Config.MaxPredictions of them.
+// POST /goplot/predict?x=10,20&level=0.95
+// POST /goplot/predict?xStart=0&xEnd=100&xStep=10
+func predictServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	level, err := floatParam(req, "level", config.PredictionLevel)
+	if err != nil || level <= 0 || level >= 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	xs, err := predictionXs(req)
+	if err != nil {
+		serveErrorFor(c, req, &compute.ParseError{Err: err})
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	result := predictions{Envelope: compute.NewEnvelope(), Level: level}
+	if result.Predictions, err = compute.Predict(series, xs, level); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonPredictions, err := json.Marshal(result)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonPredictions)
+}
+
+// the x values to predict at, from the x fields or the range
+func predictionXs(req *http.Request) ([]float64, error) {
+	if req.FormValue("xStart") == "" && req.FormValue("xEnd") == "" && req.FormValue("xStep") == "" {
+		fields := make([]string, 0)
+		for _, x := range req.Form["x"] {
+			fields = append(fields, strings.Split(x, ",")...)
+		}
+		if len(fields) == 0 {
+			return nil, errNoPredictionXs
+		}
+		if len(fields) > config.MaxPredictions {
+			return nil, errTooManyPredictions
+		}
+		xs := make([]float64, len(fields))
+		for i, field := range fields {
+			x, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+			if err != nil {
+				return nil, err
+			}
+			xs[i] = x
+		}
+		return xs, nil
+	}
+	if req.FormValue("x") != "" {
+		return nil, errMixedXs
+	}
+	start, errStart := strconv.ParseFloat(req.FormValue("xStart"), 64)
+	end, errEnd := strconv.ParseFloat(req.FormValue("xEnd"), 64)
+	step, errStep := strconv.ParseFloat(req.FormValue("xStep"), 64)
+	if errStart != nil || errEnd != nil || errStep != nil || !(step > 0) || !(end >= start) || math.IsInf(end-start, 0) {
+		return nil, errBadXRange
+	}
+	// counted before any are made, so a tiny step can't run away; the
+	// small allowance keeps an end that is a whole number of steps away
+	count := math.Floor((end-start)/step*(1+1e-12)) + 1
+	if count > float64(config.MaxPredictions) {
+		return nil, errTooManyPredictions
+	}
+	xs := make([]float64, int(count))
+	for i := range xs {
+		xs[i] = start + float64(i)*step
+	}
+	return xs, nil
+}
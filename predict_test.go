@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestPredictXs(t *testing.T) {
+	saved := config.MaxPredictions
+	defer func() { config.MaxPredictions = saved }()
+	config.MaxPredictions = 5
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7\n4,8"}}
+
+	tests := []struct {
+		query string
+		want  []float64
+	}{
+		{"x=1,2&x=3", []float64{1, 2, 3}},
+		{"x=1,2,3,4,5", []float64{1, 2, 3, 4, 5}},
+		{"xStart=0&xEnd=1&xStep=0.25", []float64{0, 0.25, 0.5, 0.75, 1}},
+		// 0.3 is a whole number of 0.1 steps, give or take rounding
+		{"xStart=0&xEnd=0.3&xStep=0.1", []float64{0, 0.1, 0.2, 0.30000000000000004}},
+		{"xStart=2&xEnd=2&xStep=1", []float64{2}},
+	}
+	for _, test := range tests {
+		rec := postForm(predictServer, "/goplot/predict?"+test.query, form)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: got %d: %s", test.query, rec.Code, rec.Body)
+			continue
+		}
+		var result predictions
+		if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatal(err)
+		}
+		xs := make([]float64, len(result.Predictions))
+		for i, prediction := range result.Predictions {
+			xs[i] = prediction.X
+			// y = 2.1x
+			if math.Abs(prediction.Y-2.1*prediction.X) > 1e-9 {
+				t.Errorf("%s: y(%v) = %v, want %v", test.query, prediction.X, prediction.Y, 2.1*prediction.X)
+			}
+		}
+		if !reflect.DeepEqual(xs, test.want) {
+			t.Errorf("%s: predicted at %v, want %v", test.query, xs, test.want)
+		}
+	}
+
+	for _, query := range []string{
+		"x=1,2,3,4,5,6",
+		"x=1,2,3&x=4,5,6",
+		"xStart=0&xEnd=10&xStep=1",
+		"xStart=0&xEnd=1&xStep=1e-300",
+		"xStart=0&xEnd=1&xStep=0",
+		"xStart=1&xEnd=0&xStep=1",
+		"xStart=0&xEnd=1",
+		"x=1&xStart=0&xEnd=1&xStep=1",
+		"x=one",
+		"",
+	} {
+		if rec := postForm(predictServer, "/goplot/predict?"+query, form); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: got %d, want %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// the first points of a data series, to check it is read as intended
+type preview struct {
+	compute.Envelope
+	Series      []compute.Point     `json:"series"`
+	ParseErrors []compute.LineError `json:"parseErrors"`
+}
+
+// parses the first n points of the posted data series, without fitting.
+// Reading stops there, so it is cheap even for a large body.
+// POST /goplot/preview?n=10
+func previewServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	n, err := intParam(req, "n", 10)
+	if err != nil || n < 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	parseOptions := compute.ParseOptions{CSVMode: req.FormValue("csvMode"),
+		XColumn: req.FormValue("xcol"),
+		YColumn: req.FormValue("ycol"),
+		Limit:   n}
+	src, err := dataSeriesFromRequest(req)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	parsed, err := compute.ParseColumnsContext(req.Context(), src, parseOptions)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	result := preview{Envelope: compute.NewEnvelope(), Series: parsed.Series, ParseErrors: parsed.Errors}
+	if result.ParseErrors == nil {
+		result.ParseErrors = make([]compute.LineError, 0)
+	}
+
+	jsonPreview, err := json.Marshal(result)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonPreview)
+}
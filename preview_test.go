@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPreview(t *testing.T) {
+	// a bad line among the first five points, and one after them that
+	// isn't reached
+	data := "1,1\n2,2\nthree,3\n3,3\n4,4\n5,5\n6,6\nseven,7\n" + strings.Repeat("8,8\n", 1000)
+	rec := postForm(previewServer, "/goplot/preview?n=5", url.Values{"dataseries": {data}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var result preview
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Series) != 5 || result.Series[4] != (compute.Point{X: 5, Y: 5}) {
+		t.Errorf("got %v, want the first 5 points", result.Series)
+	}
+	if len(result.ParseErrors) != 1 || result.ParseErrors[0].Line != 3 || result.ParseErrors[0].Raw != "three,3" ||
+		result.ParseErrors[0].Reason == "" {
+		t.Errorf("parse errors %v, want only line 3", result.ParseErrors)
+	}
+
+	for _, n := range []string{"0", "x"} {
+		if rec := postForm(previewServer, "/goplot/preview?n="+n, url.Values{"dataseries": {data}}); rec.Code != http.StatusBadRequest {
+			t.Errorf("n=%s: got %d, want %d", n, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"goplot/compute"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// gathers the provenance of a request while it is handled: the raw body is
+// hashed as the form is read from it
+type provenanceRecorder struct {
+	receivedAt time.Time
+	body       io.ReadCloser
+	hash       hash.Hash
+}
+
+// starts recording the provenance of req, before its form is read; nil
+// unless Config.TrackProvenance is set
+func startProvenance(req *http.Request) *provenanceRecorder {
+	if !config.TrackProvenance {
+		return nil
+	}
+	p := &provenanceRecorder{receivedAt: time.Now(), body: req.Body, hash: sha256.New()}
+	if req.Body != nil {
+		req.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(req.Body, p.hash), req.Body}
+	}
+	return p
+}
+
+// the provenance of the request so far. The part of the body the handler
+// left unread, like a multipart epilogue, is hashed too, up to the upload
+// limit.
+func (p *provenanceRecorder) finish(req *http.Request) *compute.Provenance {
+	if p == nil {
+		return nil
+	}
+	if req.Body != nil {
+		io.Copy(ioutil.Discard, io.LimitReader(req.Body, maxUploadBytes))
+	}
+	return &compute.Provenance{ReceivedAt: p.receivedAt.UTC(),
+		BodyHash:             hex.EncodeToString(p.hash.Sum(nil)),
+		RemoteAddr:           req.RemoteAddr,
+		UserAgent:            req.UserAgent(),
+		ProcessingDurationMs: time.Since(p.receivedAt).Milliseconds()}
+}
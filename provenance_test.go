@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	saved := config.TrackProvenance
+	defer func() { config.TrackProvenance = saved }()
+	form := url.Values{"dataseries": {"1,2\n2,4\n3,7"}}
+	sum := sha256.Sum256([]byte(form.Encode()))
+	wantHash := hex.EncodeToString(sum[:])
+
+	config.TrackProvenance = false
+	rec := postForm(dataSampleServer, "/goplot/viz", form)
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), `"provenance"`) {
+		t.Errorf("provenance without TrackProvenance: %d %s", rec.Code, rec.Body)
+	}
+
+	config.TrackProvenance = true
+	provenance := postViz(t, form).Provenance
+	if provenance == nil {
+		t.Fatal("no provenance")
+	}
+	// httptest.NewRequest's client
+	if provenance.BodyHash != wantHash || provenance.RemoteAddr != "192.0.2.1:1234" || provenance.ReceivedAt.IsZero() {
+		t.Errorf("got %+v, want body hash %s", provenance, wantHash)
+	}
+
+	// stored with the series it updated
+	storeSeries(t, "audited", "1,2\n2,4\n3,7")
+	stored, err := loadSeries("audited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Provenance == nil || stored.Provenance.BodyHash != wantHash {
+		t.Errorf("stored provenance %+v, want body hash %s", stored.Provenance, wantHash)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// how far back VolumeLimiter counts, in volumeSlots one second slots
+const volumeSlots = 60
+
+// the request body bytes a client sent, per second over the last minute
+type volumeWindow struct {
+	// ring of slots by unix second modulo volumeSlots
+	seconds [volumeSlots]int64
+	bytes   [volumeSlots]int64
+	last    int64
+}
+
+// bytes in the minute up to now
+func (w *volumeWindow) total(now int64) (total int64) {
+	for i := range w.bytes {
+		if now-w.seconds[i] < volumeSlots {
+			total += w.bytes[i]
+		}
+	}
+	return total
+}
+
+func (w *volumeWindow) add(now, n int64) {
+	slot := now % volumeSlots
+	if w.seconds[slot] != now {
+		w.seconds[slot], w.bytes[slot] = now, 0
+	}
+	w.bytes[slot] += n
+	w.last = now
+}
+
+// caps the request body bytes each client IP sends per minute
+type VolumeLimiter struct {
+	mu        sync.Mutex
+	limit     int64
+	clients   map[string]*volumeWindow
+	lastSweep int64
+}
+
+func NewVolumeLimiter(bytesPerMinute int64) *VolumeLimiter {
+	return &VolumeLimiter{limit: bytesPerMinute, clients: make(map[string]*volumeWindow)}
+}
+
+// whether the client may send n more bytes now. A body of unknown length,
+// n < 0, is let through as long as the client is under the limit and
+// counted by Record once read.
+func (l *VolumeLimiter) Allow(client string, n int64, now time.Time) bool {
+	if n < 0 {
+		n = 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	w, ok := l.clients[client]
+	if !ok {
+		return n <= l.limit
+	}
+	return w.total(now.Unix())+n <= l.limit
+}
+
+// counts n bytes the client sent now
+func (l *VolumeLimiter) Record(client string, n int64, now time.Time) {
+	if n <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	unix := now.Unix()
+	// clients quiet for a minute have nothing left to count
+	if unix-l.lastSweep >= volumeSlots {
+		for name, w := range l.clients {
+			if unix-w.last >= volumeSlots {
+				delete(l.clients, name)
+			}
+		}
+		l.lastSweep = unix
+	}
+	w, ok := l.clients[client]
+	if !ok {
+		w = &volumeWindow{}
+		l.clients[client] = w
+	}
+	w.add(unix, n)
+}
+
+// turns away requests from clients that sent more than the limiter allows
+// in the last minute with 429 and Retry-After
+func limitVolume(h http.Handler, limiter *VolumeLimiter) http.Handler {
+	return http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		client := remoteHost(req)
+		if !limiter.Allow(client, req.ContentLength, time.Now()) {
+			c.Header().Set("Retry-After", strconv.Itoa(volumeSlots))
+			serveError(c, req, http.StatusTooManyRequests)
+			return
+		}
+		if req.Body == nil || req.Body == http.NoBody {
+			h.ServeHTTP(c, req)
+			return
+		}
+		body := &countingBody{ReadCloser: req.Body}
+		req.Body = body
+		h.ServeHTTP(c, req)
+		limiter.Record(client, body.n, time.Now())
+	})
+}
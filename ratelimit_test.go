@@ -0,0 +1,61 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLimitVolume(t *testing.T) {
+	handler := limitVolume(http.HandlerFunc(func(c http.ResponseWriter, req *http.Request) {
+		io.Copy(io.Discard, req.Body)
+	}), NewVolumeLimiter(1000))
+	post := func(remoteAddr string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/goplot/viz", body)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := post("10.0.0.1:1234", strings.NewReader(strings.Repeat("x", 800))); rec.Code != http.StatusOK {
+		t.Errorf("first large POST: got %d, want %d", rec.Code, http.StatusOK)
+	}
+	rec := post("10.0.0.1:1235", strings.NewReader(strings.Repeat("x", 800)))
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("second large POST: got %d with Retry-After %q, want %d with 60", rec.Code, rec.Header().Get("Retry-After"), http.StatusTooManyRequests)
+	}
+	if rec := post("10.0.0.2:1234", strings.NewReader(strings.Repeat("x", 800))); rec.Code != http.StatusOK {
+		t.Errorf("another client: got %d, want %d", rec.Code, http.StatusOK)
+	}
+	// of no announced length, so let through and counted once read
+	if rec := post("10.0.0.3:1234", io.MultiReader(strings.NewReader(strings.Repeat("x", 1200)))); rec.Code != http.StatusOK {
+		t.Errorf("chunked POST: got %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := post("10.0.0.3:1234", strings.NewReader("1,2")); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("after a chunked POST over the limit: got %d, want %d", rec.Code, http.StatusTooManyRequests)
+	}
+}
+
+func TestVolumeLimiterWindow(t *testing.T) {
+	limiter := NewVolumeLimiter(1000)
+	start := time.Unix(1700000000, 0)
+	limiter.Record("client", 600, start)
+	limiter.Record("client", 300, start.Add(30*time.Second))
+	if limiter.Allow("client", 200, start.Add(59*time.Second)) {
+		t.Errorf("allowed 1100 bytes in a minute")
+	}
+	// the first 600 bytes are out of the window
+	if !limiter.Allow("client", 200, start.Add(60*time.Second)) {
+		t.Errorf("not allowed once the first bytes are a minute old")
+	}
+	if !limiter.Allow("client", 1000, start.Add(90*time.Second)) {
+		t.Errorf("not allowed after a quiet minute")
+	}
+	if limiter.Allow("newcomer", 1001, start) {
+		t.Errorf("allowed a first body over the limit")
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// a named series as kept in the data directory
+type StoredSeries struct {
+	Series []compute.Point `json:"series"`
+	// the least squares fit of Series, kept current by saveSeries
+	Regression *compute.RegressionLine `json:"regression,omitempty"`
+	// when saveSeries last wrote it; zero for series saved before this was
+	// kept, which go by the file's modification time
+	LastUpdated time.Time `json:"lastUpdated,omitzero"`
+	// of the request that last updated it, with Config.TrackProvenance
+	Provenance *compute.Provenance `json:"provenance,omitempty"`
+}
+
+type AppendSample struct {
+	compute.DataSample
+	PreviousPointCount int `json:"previousPointCount"`
+	NewPointCount      int `json:"newPointCount"`
+}
+
+var errBadSeriesName = errors.New("invalid series name")
+
+// handles the named series under /goplot/series/:
+// GET /goplot/series/{name}
+// DELETE /goplot/series/{name}
+// POST /goplot/series/{name}/append
+// POST /goplot/series/{name}/copy?as=newname[&overwrite=true]
+func seriesServer(c http.ResponseWriter, req *http.Request) {
+	path := strings.TrimPrefix(req.URL.Path, config.BasePath+"/goplot/series/")
+	name, action := path, ""
+	if i := strings.Index(path, "/"); i >= 0 {
+		name, action = path[:i], path[i+1:]
+	}
+	if validSeriesName(name) != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if !seriesAllowed(req.Header.Get("X-API-Key"), name) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+
+	switch action {
+	case "":
+		switch req.Method {
+		case "GET":
+			seriesGet(c, req, name)
+		case "DELETE":
+			seriesDelete(c, req, name)
+		default:
+			serveError(c, req, http.StatusMethodNotAllowed)
+		}
+	case "append":
+		if req.Method != "POST" {
+			serveError(c, req, http.StatusMethodNotAllowed)
+			return
+		}
+		seriesAppend(c, req, name)
+	case "copy":
+		if req.Method != "POST" {
+			serveError(c, req, http.StatusMethodNotAllowed)
+			return
+		}
+		seriesCopy(c, req, name)
+	default:
+		serveError(c, req, http.StatusNotFound)
+	}
+}
+
+// a stored series in the list at /goplot/series
+type SeriesSummary struct {
+	Name        string    `json:"name"`
+	PointCount  int       `json:"pointCount"`
+	Slope       *float64  `json:"slope,omitempty"`
+	RSquared    *float64  `json:"rSquared,omitempty"`
+	LastUpdated time.Time `json:"lastUpdated"`
+	// no update for Config.StaleAfterMinutes
+	Stale bool `json:"stale"`
+}
+
+// lists the stored series the API key may use, with their fits
+// GET /goplot/series
+func seriesListServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "GET" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	paths, err := filepath.Glob(filepath.Join(config.DataDir, "*.json"))
+	if err != nil {
+		serveErrorFor(c, req, &StorageError{Series: "*", Err: err})
+		return
+	}
+	summaries := make([]SeriesSummary, 0, len(paths))
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".json")
+		if validSeriesName(name) != nil || !seriesAllowed(req.Header.Get("X-API-Key"), name) {
+			continue
+		}
+		summary, err := summarizeSeries(name)
+		if os.IsNotExist(errors.Unwrap(err)) {
+			// deleted since the glob
+			continue
+		} else if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+
+	jsonSummaries, err := json.Marshal(struct {
+		compute.Envelope
+		Series []SeriesSummary `json:"series"`
+	}{compute.NewEnvelope(), summaries})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSummaries)
+}
+
+// whether a series last updated then has gone without updates for too long
+func seriesStale(lastUpdated time.Time) bool {
+	return time.Since(lastUpdated) > time.Duration(config.StaleAfterMinutes)*time.Minute
+}
+
+// the names of the stored series that are stale, for the readiness report.
+// saveSeries writes each series to a new file, so its modification time is
+// when it was last updated; the files aren't read or locked, keeping the
+// probe cheap however much data is stored.
+func staleSeries() []string {
+	stale := make([]string, 0)
+	entries, _ := os.ReadDir(config.DataDir)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		if name == entry.Name() || validSeriesName(name) != nil {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() && seriesStale(info.ModTime()) {
+			stale = append(stale, name)
+		}
+	}
+	return stale
+}
+
+func summarizeSeries(name string) (summary SeriesSummary, err error) {
+	lock := seriesLocks.Lock(name)
+	lock.RLock()
+	defer lock.RUnlock()
+	info, err := os.Stat(seriesPath(name))
+	if err != nil {
+		return summary, &StorageError{Series: name, Err: err}
+	}
+	stored, err := loadSeries(name)
+	if err != nil {
+		return summary, err
+	}
+	lastUpdated := stored.LastUpdated
+	if lastUpdated.IsZero() {
+		lastUpdated = info.ModTime()
+	}
+	summary = SeriesSummary{Name: name,
+		PointCount:  len(stored.Series),
+		LastUpdated: lastUpdated.UTC(),
+		Stale:       seriesStale(lastUpdated)}
+	if stored.Regression != nil {
+		rSquared := stored.Regression.Correlation * stored.Regression.Correlation
+		summary.Slope, summary.RSquared = &stored.Regression.Slope, &rSquared
+	}
+	return summary, nil
+}
+
+// sends back a stored series with its stored fit
+func seriesGet(c http.ResponseWriter, req *http.Request, name string) {
+	lock := seriesLocks.Lock(name)
+	lock.RLock()
+	defer lock.RUnlock()
+	if !seriesExists(name) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	dataSample := &compute.DataSample{Series: stored.Series,
+		Envelope:       compute.NewEnvelope(),
+		RegressionLine: stored.Regression,
+		Metadata:       metadataFromRequest(req),
+		Provenance:     stored.Provenance}
+	jsonDataSample, err := json.Marshal(dataSample)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonDataSample)
+}
+
+// removes a stored series
+func seriesDelete(c http.ResponseWriter, req *http.Request, name string) {
+	lock := seriesLocks.Lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	err := os.Remove(seriesPath(name))
+	if os.IsNotExist(err) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	} else if err != nil {
+		serveErrorFor(c, req, &StorageError{Series: name, Err: err})
+		return
+	}
+	c.WriteHeader(http.StatusNoContent)
+}
+
+// appends the posted points to a stored series and refits the whole thing
+func seriesAppend(c http.ResponseWriter, req *http.Request, name string) {
+	provenance := startProvenance(req)
+	points, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	lock := seriesLocks.Lock(name)
+	lock.Lock()
+	defer lock.Unlock()
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	previous := len(stored.Series)
+	stored.Series = append(stored.Series, points...)
+	stored.Provenance = provenance.finish(req)
+	// made before saving: a series that can't be fitted isn't saved, so the
+	// client can't append the same points twice by retrying
+	dataSample, err := compute.FitDataSample(stored.Series, metadataFromRequest(req))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	dataSample.Provenance = stored.Provenance
+	appendSample := &AppendSample{DataSample: *dataSample,
+		PreviousPointCount: previous,
+		NewPointCount:      len(stored.Series)}
+	jsonAppendSample, err := json.Marshal(appendSample)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	if err = saveSeries(name, stored); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonAppendSample)
+}
+
+// saves the stored series under a new name and sends back the fit of the copy
+func seriesCopy(c http.ResponseWriter, req *http.Request, name string) {
+	as := req.FormValue("as")
+	if validSeriesName(as) != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if !seriesAllowed(req.Header.Get("X-API-Key"), as) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	unlock := seriesLocks.LockCopy(name, as)
+	defer unlock()
+	if !seriesExists(name) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	if overwrite, _ := strconv.ParseBool(req.FormValue("overwrite")); seriesExists(as) && !overwrite {
+		serveError(c, req, http.StatusConflict)
+		return
+	}
+
+	// the whole stored record is copied, not just the points
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	// made before saving, so nothing is written when the copy can't be fitted
+	dataSample, err := compute.FitDataSample(stored.Series, metadataFromRequest(req))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	jsonDataSample, err := json.Marshal(dataSample)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	if err = saveSeries(as, stored); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonDataSample)
+}
+
+// checks the API key against Config.SeriesACL
+func seriesAllowed(apiKey string, name string) bool {
+	if len(config.SeriesACL) == 0 {
+		return true
+	}
+	prefixes, ok := config.SeriesACL[apiKey]
+	if !ok || apiKey == "" {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix == "*" || strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config.SeriesNamePattern, compiled by main
+var seriesNamePattern *regexp.Regexp
+
+// series names map straight to file names, so keep them to a single path
+// element, besides holding them to the configured length and pattern
+func validSeriesName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return errBadSeriesName
+	}
+	if len(name) > config.SeriesNameMaxLen || !seriesNamePattern.MatchString(name) {
+		return errBadSeriesName
+	}
+	return nil
+}
+
+func seriesPath(name string) string {
+	return filepath.Join(config.DataDir, name+".json")
+}
+
+func seriesExists(name string) bool {
+	_, err := os.Stat(seriesPath(name))
+	return err == nil
+}
+
+// reads a stored series; a series that doesn't exist yet is empty
+func loadSeries(name string) (*StoredSeries, error) {
+	stored := &StoredSeries{Series: make([]compute.Point, 0)}
+	data, err := ioutil.ReadFile(seriesPath(name))
+	if os.IsNotExist(err) {
+		return stored, nil
+	} else if err != nil {
+		return nil, &StorageError{Series: name, Err: err}
+	}
+	if err = json.Unmarshal(data, stored); err != nil {
+		return nil, &StorageError{Series: name, Err: err}
+	}
+	return stored, nil
+}
+
+// writes the series to a temp file and renames it into place, so readers
+// never see a partial file and a failed write leaves the old one intact.
+// The fit of the series is stored along with it.
+func saveSeries(name string, stored *StoredSeries) (err error) {
+	defer func() {
+		if err != nil {
+			err = &StorageError{Series: name, Err: err}
+		}
+	}()
+	stored.Regression = seriesFit(stored.Series)
+	stored.LastUpdated = time.Now().UTC()
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(config.DataDir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(config.DataDir, name+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}()
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), seriesPath(name))
+}
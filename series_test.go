@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSeriesAppendPointCount(t *testing.T) {
+	for _, step := range []struct {
+		data               string
+		previous, newCount int
+	}{
+		{"1,2\n2,4\n3,7", 0, 3},
+		{"4,9\n5,11", 3, 5},
+	} {
+		rec := postForm(seriesServer, "/goplot/series/appendcount/append", url.Values{"dataseries": {step.data}})
+		if rec.Code != http.StatusOK {
+			t.Fatalf("got %d: %s", rec.Code, rec.Body)
+		}
+		var sample AppendSample
+		if err := json.Unmarshal(rec.Body.Bytes(), &sample); err != nil {
+			t.Fatal(err)
+		}
+		if sample.PreviousPointCount != step.previous || sample.NewPointCount != step.newCount || len(sample.Series) != step.newCount {
+			t.Errorf("got %d then %d points, want %d then %d", sample.PreviousPointCount, sample.NewPointCount, step.previous, step.newCount)
+		}
+	}
+	stored, err := loadSeries("appendcount")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(stored.Series) != 5 {
+		t.Errorf("stored %d points, want 5", len(stored.Series))
+	}
+}
+
+// a series that can't be fitted yet is a 422 and isn't saved, so a retry
+// can't append the points twice
+func TestSeriesAppendUnfittable(t *testing.T) {
+	rec := postForm(seriesServer, "/goplot/series/appendshort/append", url.Values{"dataseries": {"1,2"}})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got %d, want 422", rec.Code)
+	}
+	if seriesExists("appendshort") {
+		t.Error("the series was saved")
+	}
+}
+
+// appends racing on one series must each see the points of the ones
+// before them, or the last to save drops the others' points
+func TestSeriesConcurrentAppends(t *testing.T) {
+	const appenders, each = 20, 3
+	os.Remove(seriesPath("concurrent"))
+	var wg sync.WaitGroup
+	codes := make([]int, appenders)
+	for i := 0; i < appenders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := ""
+			for j := 0; j < each; j++ {
+				x := strconv.Itoa(i*each + j)
+				data += x + "," + x + "\n"
+			}
+			codes[i] = postForm(seriesServer, "/goplot/series/concurrent/append", url.Values{"dataseries": {data}}).Code
+		}(i)
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("append %d: got %d", i, code)
+		}
+	}
+	stored, err := loadSeries("concurrent")
+	if err != nil {
+		t.Fatal(err)
+	} else if len(stored.Series) != appenders*each {
+		t.Errorf("stored %d points, want %d", len(stored.Series), appenders*each)
+	}
+}
+
+func TestSaveSeriesRemovesTempFileOnFailure(t *testing.T) {
+	// a directory in the way makes the final rename fail
+	if err := os.Mkdir(seriesPath("blocked"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(seriesPath("blocked"))
+	if err := saveSeries("blocked", &StoredSeries{}); err == nil {
+		t.Fatal("saved over a directory")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(config.DataDir, "blocked.tmp*")); len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+// stores a series under name through the append endpoint
+func storeSeries(t *testing.T, name, data string) {
+	t.Helper()
+	if rec := postForm(seriesServer, "/goplot/series/"+name+"/append", url.Values{"dataseries": {data}}); rec.Code != http.StatusOK {
+		t.Fatalf("storing %s: got %d: %s", name, rec.Code, rec.Body)
+	}
+}
+
+func TestSeriesCopy(t *testing.T) {
+	storeSeries(t, "copysrc", "1,2\n2,4\n3,7\n4,8")
+	rec := postForm(seriesServer, "/goplot/series/copysrc/copy?as=copydst", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	original, _ := loadSeries("copysrc")
+	copied, _ := loadSeries("copydst")
+	if len(copied.Series) != len(original.Series) {
+		t.Errorf("copy has %d points, original %d", len(copied.Series), len(original.Series))
+	}
+	if !reflect.DeepEqual(copied.Regression, original.Regression) {
+		t.Errorf("copy fits %+v, original %+v", copied.Regression, original.Regression)
+	}
+
+	if rec := postForm(seriesServer, "/goplot/series/copysrc/copy?as=copydst", nil); rec.Code != http.StatusConflict {
+		t.Errorf("copying over an existing series: got %d, want 409", rec.Code)
+	}
+	if rec := postForm(seriesServer, "/goplot/series/copysrc/copy?as=copydst&overwrite=true", nil); rec.Code != http.StatusOK {
+		t.Errorf("copying with overwrite=true: got %d", rec.Code)
+	}
+
+	// the two are independent from here on
+	storeSeries(t, "copysrc", "5,11")
+	if copied, _ := loadSeries("copydst"); len(copied.Series) != 4 {
+		t.Errorf("appending to the original changed the copy to %d points", len(copied.Series))
+	}
+	rec = httptest.NewRecorder()
+	seriesServer(rec, httptest.NewRequest("DELETE", "/goplot/series/copysrc", nil))
+	if rec.Code != http.StatusNoContent || seriesExists("copysrc") || !seriesExists("copydst") {
+		t.Errorf("deleting the original: got %d, original exists %v, copy exists %v", rec.Code, seriesExists("copysrc"), seriesExists("copydst"))
+	}
+}
+
+// a series too short to fit isn't copied
+func TestSeriesCopyUnfittable(t *testing.T) {
+	if err := saveSeries("copyshort", &StoredSeries{Series: []compute.Point{{X: 1, Y: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	if rec := postForm(seriesServer, "/goplot/series/copyshort/copy?as=copyshort2", nil); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("got %d, want 422", rec.Code)
+	}
+	if seriesExists("copyshort2") {
+		t.Error("the copy was saved")
+	}
+}
+
+func TestValidSeriesName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"cpu_load-1", true},
+		{strings.Repeat("a", 64), true},
+		{strings.Repeat("a", 65), false},
+		{"cpu load", false},
+		{"cpu.load", false},
+		{"../etc", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if err := validSeriesName(test.name); (err == nil) != test.valid {
+			t.Errorf("%q: got %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+	rec := httptest.NewRecorder()
+	seriesServer(rec, httptest.NewRequest("GET", "/goplot/series/cpu%20load", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET of a bad name: got %d, want 400", rec.Code)
+	}
+}
+
+func TestSeriesNamePatternFromConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "goplot.conf")
+	if err := os.WriteFile(configPath, []byte(`{"SeriesNamePattern": "^[a-z]+\\.[a-z]+$", "SeriesNameMaxLen": 8}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedPattern := config, seriesNamePattern
+	defer func() { config, seriesNamePattern = saved, savedPattern }()
+	config.SeriesNamePattern, config.SeriesNameMaxLen = loaded.SeriesNamePattern, loaded.SeriesNameMaxLen
+	seriesNamePattern = regexp.MustCompile(config.SeriesNamePattern)
+
+	for name, valid := range map[string]bool{"cpu.load": true, "cpu_load": false, "cpu.loads": false} {
+		if err := validSeriesName(name); (err == nil) != valid {
+			t.Errorf("%q: got %v, want valid %v", name, err, valid)
+		}
+	}
+
+	for _, pattern := range []string{``, `[a-z`} {
+		body := `{"SeriesNamePattern": "` + pattern + `"}`
+		if err := os.WriteFile(configPath, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		var configError *ConfigError
+		if _, err := LoadConfig(configPath); !errors.As(err, &configError) || configError.Field != "SeriesNamePattern" {
+			t.Errorf("pattern %q: got %v, want a SeriesNamePattern error", pattern, err)
+		}
+	}
+}
+
+func TestSeriesACL(t *testing.T) {
+	storeSeries(t, "alice-load", "1,2\n2,4\n3,7")
+	storeSeries(t, "bob-load", "1,2\n2,4\n3,7")
+	saved := config.SeriesACL
+	defer func() { config.SeriesACL = saved }()
+	config.SeriesACL = map[string][]string{"alice-key": {"alice-"}, "admin-key": {"*"}}
+
+	tests := []struct {
+		apiKey, series string
+		want           int
+	}{
+		{"alice-key", "alice-load", http.StatusOK},
+		{"alice-key", "bob-load", http.StatusForbidden},
+		{"wrong-key", "alice-load", http.StatusForbidden},
+		{"", "alice-load", http.StatusForbidden},
+		{"admin-key", "alice-load", http.StatusOK},
+		{"admin-key", "bob-load", http.StatusOK},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/goplot/series/"+test.series, nil)
+		req.Header.Set("X-API-Key", test.apiKey)
+		rec := httptest.NewRecorder()
+		seriesServer(rec, req)
+		if rec.Code != test.want {
+			t.Errorf("%s with key %q: got %d, want %d", test.series, test.apiKey, rec.Code, test.want)
+		}
+	}
+
+	// nor can a key write outside its prefixes
+	req := httptest.NewRequest("POST", "/goplot/series/bob-load/append", strings.NewReader("dataseries=4,9"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-API-Key", "alice-key")
+	rec := httptest.NewRecorder()
+	seriesServer(rec, req)
+	if stored, _ := loadSeries("bob-load"); rec.Code != http.StatusForbidden || len(stored.Series) != 3 {
+		t.Errorf("appending to another's series: got %d, %d points stored", rec.Code, len(stored.Series))
+	}
+
+	// the stateless /goplot/viz takes no key
+	postViz(t, url.Values{"dataseries": {"1,2\n2,4\n3,7"}})
+}
+
+func TestSeriesStale(t *testing.T) {
+	storeSeries(t, "fresh", "1,2\n2,4\n3,7")
+	storeSeries(t, "backdated", "1,2\n2,4\n3,7")
+	stored, err := loadSeries("backdated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// written directly, as saveSeries would set it to now
+	stored.LastUpdated = time.Now().Add(-time.Duration(config.StaleAfterMinutes+1) * time.Minute)
+	data, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(seriesPath("backdated"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// what the readiness report goes by
+	if err := os.Chtimes(seriesPath("backdated"), stored.LastUpdated, stored.LastUpdated); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	seriesListServer(rec, httptest.NewRequest("GET", "/goplot/series", nil))
+	var listing struct{ Series []SeriesSummary }
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil {
+		t.Fatal(err)
+	}
+	stale := make(map[string]bool)
+	for _, summary := range listing.Series {
+		stale[summary.Name] = summary.Stale
+	}
+	if fresh, ok := stale["fresh"]; !ok || fresh {
+		t.Errorf("fresh series: listed %v, stale %v", ok, fresh)
+	}
+	if backdated, ok := stale["backdated"]; !ok || !backdated {
+		t.Errorf("backdated series: listed %v, stale %v", ok, backdated)
+	}
+
+	readiness, _ := getReadiness(t)
+	reported := make(map[string]bool)
+	for _, name := range readiness.StaleSeries {
+		reported[name] = true
+	}
+	if !reported["backdated"] || reported["fresh"] {
+		t.Errorf("readiness stale series %v, want backdated and not fresh among them", readiness.StaleSeries)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"goplot/compute"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// name of the cookie holding the session ID
+const sessionCookie = "goplot_session"
+
+var errSessionFull = errors.New("session holds more than MaxPoints points")
+
+// the points posted so far in a session
+type SessionData struct {
+	Series   []compute.Point
+	lastUsed time.Time
+}
+
+// in-memory sessions by ID, for /goplot/viz?session=true. A session is
+// dropped once unused for the TTL.
+type SessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]*SessionData
+	ttl      time.Duration
+}
+
+// the session store, set up by main
+var sessions *SessionStore
+
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{sessions: make(map[string]*SessionData), ttl: ttl}
+}
+
+// whether the session is there and hasn't expired
+func (store *SessionStore) Exists(id string) bool {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	session, ok := store.sessions[id]
+	return ok && time.Since(session.lastUsed) < store.ttl
+}
+
+// adds points to the session, creating it if need be, and returns all of
+// its points so far. The points so far also go to the live feed.
+func (store *SessionStore) Append(id string, points []compute.Point) ([]compute.Point, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	session, ok := store.sessions[id]
+	if !ok || time.Since(session.lastUsed) >= store.ttl {
+		session = &SessionData{Series: make([]compute.Point, 0, len(points))}
+		store.sessions[id] = session
+	}
+	if len(session.Series)+len(points) > config.MaxPoints {
+		return nil, errSessionFull
+	}
+	session.Series = append(session.Series, points...)
+	session.lastUsed = time.Now()
+	accumulated := make([]compute.Point, len(session.Series))
+	copy(accumulated, session.Series)
+	live.Publish(id, accumulated)
+	return accumulated, nil
+}
+
+// a copy of the session's points, nil when there is no such session
+func (store *SessionStore) Series(id string) []compute.Point {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	session, ok := store.sessions[id]
+	if !ok || time.Since(session.lastUsed) >= store.ttl {
+		return nil
+	}
+	series := make([]compute.Point, len(session.Series))
+	copy(series, session.Series)
+	return series
+}
+
+// drops the sessions unused for the TTL, checking every interval
+func (store *SessionStore) EvictEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		store.evictExpired(time.Now())
+	}
+}
+
+func (store *SessionStore) evictExpired(now time.Time) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for id, session := range store.sessions {
+		if now.Sub(session.lastUsed) >= store.ttl {
+			delete(store.sessions, id)
+		}
+	}
+}
+
+// the ID from the session cookie, or a new one that is then set as the cookie
+func sessionID(c http.ResponseWriter, req *http.Request) (string, error) {
+	if cookie, err := req.Cookie(sessionCookie); err == nil && sessions.Exists(cookie.Value) {
+		return cookie.Value, nil
+	}
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(random)
+	http.SetCookie(c, &http.Cookie{Name: sessionCookie,
+		Value:    id,
+		Path:     config.BasePath + "/goplot",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode})
+	return id, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// posts data to /goplot/viz?session=true with the cookies
+func postSession(t *testing.T, data string, cookies []*http.Cookie) (compute.DataSample, []*http.Cookie) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/goplot/viz?session=true", strings.NewReader(url.Values{"dataseries": {data}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	dataSampleServer(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var dataSample compute.DataSample
+	if err := json.Unmarshal(rec.Body.Bytes(), &dataSample); err != nil {
+		t.Fatal(err)
+	}
+	return dataSample, rec.Result().Cookies()
+}
+
+func TestSessionAccumulates(t *testing.T) {
+	saved := sessions
+	defer func() { sessions = saved }()
+	sessions = NewSessionStore(time.Hour)
+
+	first, cookies := postSession(t, "1,2\n2,4\n3,6", nil)
+	if len(cookies) != 1 || cookies[0].Name != sessionCookie || len(first.Series) != 3 {
+		t.Fatalf("got cookies %v and %d points", cookies, len(first.Series))
+	}
+	second, again := postSession(t, "4,8\n5,10\n6,12", cookies)
+	if len(second.Series) != 6 || second.RegressionLine.Slope != 2 {
+		t.Errorf("got %d points with slope %v, want the 6 so far with slope 2", len(second.Series), second.RegressionLine.Slope)
+	}
+	if len(again) != 0 {
+		t.Errorf("a live session got a new cookie: %v", again)
+	}
+
+	// unused for the TTL
+	sessions.evictExpired(time.Now().Add(time.Hour))
+	if series := sessions.Series(cookies[0].Value); series != nil {
+		t.Errorf("expired session kept %v", series)
+	}
+	third, renewed := postSession(t, "7,14\n8,16\n9,18", cookies)
+	if len(third.Series) != 3 || len(renewed) != 1 || renewed[0].Value == cookies[0].Value {
+		t.Errorf("after expiry got %d points and cookies %v, want a fresh session", len(third.Series), renewed)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+	"sync"
+)
+
+var errSharedFull = errors.New("shared series holds more than MaxPoints points")
+
+// a single series for the whole server that any client can add to. Each
+// method holds the lock throughout, so a snapshot never sees half an append.
+type SharedSeries struct {
+	mu     sync.RWMutex
+	series []compute.Point
+}
+
+// the shared series behind /goplot/shared
+var shared = &SharedSeries{}
+
+// adds points to the series, returning how many it held before and a
+// snapshot taken along with the append
+func (s *SharedSeries) Append(points []compute.Point) (previous int, snapshot []compute.Point, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous = len(s.series)
+	if previous+len(points) > config.MaxPoints {
+		return previous, nil, errSharedFull
+	}
+	s.series = append(s.series, points...)
+	return previous, s.copy(), nil
+}
+
+// a copy of the points so far
+func (s *SharedSeries) Snapshot() []compute.Point {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.copy()
+}
+
+// drops all the points
+func (s *SharedSeries) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.series = nil
+}
+
+func (s *SharedSeries) copy() []compute.Point {
+	snapshot := make([]compute.Point, len(s.series))
+	copy(snapshot, s.series)
+	return snapshot
+}
+
+// the points with their fit, leaving the line out while there are too few
+// points, or too little spread, for one. The series starts out empty.
+func sharedSample(snapshot []compute.Point, metadata compute.Metadata) compute.DataSample {
+	return compute.DataSample{Series: snapshot,
+		Envelope:       compute.NewEnvelope(),
+		RegressionLine: seriesFit(snapshot),
+		Metadata:       metadata}
+}
+
+// handles the shared series:
+// GET /goplot/shared fits a snapshot of it
+// POST /goplot/shared appends the dataseries points and fits the result
+// DELETE /goplot/shared clears it
+func sharedServer(c http.ResponseWriter, req *http.Request) {
+	var response interface{}
+	switch req.Method {
+	case "GET":
+		sample := sharedSample(shared.Snapshot(), metadataFromRequest(req))
+		response = &sample
+	case "POST":
+		points, err := compute.ParseSeries(req.FormValue("dataseries"))
+		if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		previous, snapshot, err := shared.Append(points)
+		if err != nil {
+			serveErrorFor(c, req, err)
+			return
+		}
+		response = &AppendSample{DataSample: sharedSample(snapshot, metadataFromRequest(req)),
+			PreviousPointCount: previous,
+			NewPointCount:      len(snapshot)}
+	case "DELETE":
+		shared.Clear()
+		c.WriteHeader(http.StatusNoContent)
+		return
+	default:
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+
+	jsonResponse, err := json.Marshal(response)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonResponse)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+)
+
+// run with -race: appends and snapshots from many goroutines at once
+func TestSharedSeriesConcurrentAppendSnapshot(t *testing.T) {
+	s := &SharedSeries{}
+	const writers, appends, batch = 8, 50, 3
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			points := make([]compute.Point, batch)
+			for i := 0; i < appends; i++ {
+				if _, _, err := s.Append(points); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for i := 0; i < appends; i++ {
+				// never half an append
+				if n := len(s.Snapshot()); n%batch != 0 {
+					t.Errorf("snapshot of %d points", n)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(s.Snapshot()); n != writers*appends*batch {
+		t.Errorf("got %d points, want %d", n, writers*appends*batch)
+	}
+}
+
+// too few points for a fit, or all at one x, leave the line out rather
+// than failing on NaN
+func TestSharedServerUnfittable(t *testing.T) {
+	defer shared.Clear()
+	shared.Clear()
+	for _, data := range []string{"", "1,2", "1,2\n1,3\n1,4"} {
+		if data != "" {
+			shared.Clear()
+			if rec := postForm(sharedServer, "/goplot/shared", url.Values{"dataseries": {data}}); rec.Code != 200 {
+				t.Fatalf("POST %q: got %d", data, rec.Code)
+			}
+		}
+		rec := httptest.NewRecorder()
+		sharedServer(rec, httptest.NewRequest("GET", "/goplot/shared", nil))
+		var sample compute.DataSample
+		if rec.Code != 200 {
+			t.Fatalf("GET after %q: got %d", data, rec.Code)
+		} else if err := json.Unmarshal(rec.Body.Bytes(), &sample); err != nil {
+			t.Fatal(err)
+		} else if sample.RegressionLine != nil {
+			t.Errorf("GET after %q: got a line %+v", data, sample.RegressionLine)
+		}
+	}
+
+	shared.Clear()
+	rec := postForm(sharedServer, "/goplot/shared", url.Values{"dataseries": {"1,2\n2,4\n3,7"}})
+	var sample AppendSample
+	if err := json.Unmarshal(rec.Body.Bytes(), &sample); err != nil {
+		t.Fatal(err)
+	} else if sample.RegressionLine == nil || sample.NewPointCount != 3 {
+		t.Errorf("got %+v, want a line through 3 points", sample)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// bootstraps the posted data series and returns the resampled lines with
+// the envelope they form
+// POST /goplot/envelope?n=50&alpha=0.05
+func envelopeServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	resamples, err := intParam(req, "n", 50)
+	if err != nil || resamples < 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if resamples > config.MaxBootstrap {
+		resamples = config.MaxBootstrap
+	}
+	alpha, err := floatParam(req, "alpha", 0.05)
+	if err != nil || alpha <= 0 || alpha >= 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	seed := time.Now().UnixNano()
+	if s := req.FormValue("seed"); s != "" {
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	if err = compute.CheckFittable("linear", series); err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonSimulation, err := json.Marshal(struct {
+		compute.Envelope
+		compute.Simulation
+	}{compute.NewEnvelope(), compute.SimulationEnvelope(series, resamples, alpha, seed)})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonSimulation)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestEnvelopeServer(t *testing.T) {
+	rec := postForm(envelopeServer, "/goplot/envelope?n=20&seed=1", url.Values{"dataseries": {"1,5\n2,5\n3,5\n4,5"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("flat: got %d: %s", rec.Code, rec.Body)
+	}
+	var simulation compute.Simulation
+	if err := json.Unmarshal(rec.Body.Bytes(), &simulation); err != nil {
+		t.Fatal(err)
+	}
+	if len(simulation.Lines) == 0 || len(simulation.Points) != 4 {
+		t.Fatalf("flat: got %d lines and %d envelope points", len(simulation.Lines), len(simulation.Points))
+	}
+	for _, line := range simulation.Lines {
+		if line.Slope != 0 || line.Correlation != 0 {
+			t.Errorf("flat: got line %+v", line)
+		}
+	}
+
+	for _, data := range []string{"1,2\n1,4\n1,7", "1,2\n2,4", ""} {
+		if rec := postForm(envelopeServer, "/goplot/envelope", url.Values{"dataseries": {data}}); rec.Code != http.StatusUnprocessableEntity {
+			t.Errorf("%q: got %d, want %d", data, rec.Code, http.StatusUnprocessableEntity)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"goplot/compute"
+	"net/http"
+)
+
+// bounds on a transform, which takes frames × windowSize²/2 steps: the
+// largest window, the most frames and the most DFT terms over all frames
+const maxSTFTWindow = 4096
+const maxSTFTFrames = 1024
+const maxSTFTTerms = 1 << 28
+
+var errSTFTWindow = errors.New("windowSize must be between 2 and 4096")
+var errSTFTFrames = errors.New("more than 1024 frames, raise hopSize")
+var errSTFTTerms = errors.New("transform too large, raise hopSize or lower windowSize")
+
+// computes the short-time Fourier transform of the posted data series
+// POST /goplot/stft?windowSize=64&hopSize=32&windowFunction=hann
+func stftServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+
+	windowSize, err := intParam(req, "windowSize", 64)
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if windowSize < 2 || windowSize > maxSTFTWindow {
+		serveErrorFor(c, req, &compute.ParseError{Err: errSTFTWindow})
+		return
+	}
+	hopSize, err := intParam(req, "hopSize", windowSize/2)
+	if err != nil || hopSize < 1 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	windowName := req.FormValue("windowFunction")
+	if windowName == "" {
+		windowName = "hann"
+	}
+	window, ok := compute.WindowFunctions[windowName]
+	if !ok {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil || len(series) < windowSize {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	frames := compute.STFTFrameCount(len(series), windowSize, hopSize)
+	if frames > maxSTFTFrames {
+		serveErrorFor(c, req, &compute.ParseError{Err: errSTFTFrames})
+		return
+	}
+	if frames*(windowSize/2+1)*windowSize > maxSTFTTerms {
+		serveErrorFor(c, req, &compute.ParseError{Err: errSTFTTerms})
+		return
+	}
+
+	transform, uniform := compute.STFT(series, windowSize, hopSize, window)
+	if !uniform {
+		c.Header().Set("Warning", `199 goplot "non-uniform X spacing, frequencies are approximate"`)
+	}
+
+	jsonFrames, err := json.Marshal(struct {
+		compute.Envelope
+		Frames []compute.STFTFrame `json:"frames"`
+	}{compute.NewEnvelope(), transform})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonFrames)
+}
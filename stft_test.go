@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// n points of a ramp, as a dataseries
+func rampSeries(n int) string {
+	var data strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&data, "%d,%d\n", i, i%7)
+	}
+	return data.String()
+}
+
+func TestSTFTCaps(t *testing.T) {
+	tests := []struct {
+		name                      string
+		points                    int
+		windowSize, hopSize, want string
+	}{
+		{"within the caps", 512, "64", "32", "200"},
+		{"window too large", 8192, "8192", "4096", "400"},
+		{"too many frames", 5000, "64", "1", "400"},
+		{"too many terms", 8192, "4096", "16", "400"},
+	}
+	for _, test := range tests {
+		form := url.Values{"dataseries": {rampSeries(test.points)}, "windowSize": {test.windowSize}, "hopSize": {test.hopSize}}
+		rec := postForm(stftServer, "/goplot/stft", form)
+		if got := fmt.Sprint(rec.Code); got != test.want {
+			t.Errorf("%s: got %s, want %s: %s", test.name, got, test.want, rec.Body)
+		}
+		if test.want == "400" && !strings.Contains(rec.Body.String(), "windowSize") && !strings.Contains(rec.Body.String(), "hopSize") {
+			t.Errorf("%s: unhelpful error %q", test.name, rec.Body)
+		}
+	}
+	if rec := postForm(stftServer, "/goplot/stft", url.Values{"dataseries": {rampSeries(512)}}); rec.Code != http.StatusOK {
+		t.Errorf("defaults: got %d", rec.Code)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"io"
+	"math"
+	"net/http"
+)
+
+// largest JSON body /goplot/from-sums reads
+const maxSumsBody = 1 << 10
+
+// fits a line to aggregates of a series rather than its points, for data
+// that can't be uploaded
+// POST /goplot/from-sums {"n": 10, "sumX": .., "sumY": .., "sumXY": .., "sumX2": .., "sumY2": ..}
+func fromSumsServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	var sums compute.RegressionSums
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxSumsBody)).Decode(&sums); err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if sums.N < 2 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	for _, sum := range []float64{sums.SumX, sums.SumY, sums.SumXY, sums.SumX2, sums.SumY2} {
+		if math.IsInf(sum, 0) || math.IsNaN(sum) {
+			serveError(c, req, http.StatusBadRequest)
+			return
+		}
+	}
+	line, err := compute.LineFromSums(sums)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+
+	jsonLine, err := json.Marshal(struct {
+		compute.Envelope
+		compute.RegressionLine
+	}{compute.NewEnvelope(), line})
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonLine)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func postSums(body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	fromSumsServer(rec, httptest.NewRequest("POST", "/goplot/from-sums", strings.NewReader(body)))
+	return rec
+}
+
+func TestFromSumsMatchesBatch(t *testing.T) {
+	series := []compute.Point{{X: 1, Y: 2.1}, {X: 2, Y: 3.9}, {X: 3, Y: 6.2}, {X: 4, Y: 7.8}, {X: 5, Y: 10.1}, {X: 6, Y: 11.7}}
+	sums := compute.RegressionSums{N: len(series)}
+	for _, pt := range series {
+		sums.SumX += pt.X
+		sums.SumY += pt.Y
+		sums.SumXY += pt.X * pt.Y
+		sums.SumX2 += pt.X * pt.X
+		sums.SumY2 += pt.Y * pt.Y
+	}
+	body, _ := json.Marshal(sums)
+	rec := postSums(string(body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var line compute.RegressionLine
+	if err := json.Unmarshal(rec.Body.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+
+	slope, intercept, stdError, correlation := compute.LinearRegression(series)
+	for _, value := range []struct {
+		name      string
+		got, want float64
+	}{{"slope", line.Slope, slope}, {"intercept", line.Intercept, intercept},
+		{"stdError", line.StdError, stdError}, {"correlation", line.Correlation, correlation}} {
+		if math.Abs(value.got-value.want) > 1e-9*math.Max(1, math.Abs(value.want)) {
+			t.Errorf("%s: got %v, batch gives %v", value.name, value.got, value.want)
+		}
+	}
+
+	for _, bad := range []string{
+		`{"n": 1, "sumX": 1, "sumY": 1, "sumXY": 1, "sumX2": 1, "sumY2": 1}`,
+		`{"n": 3, "sumX": 1e400}`,
+		`not json`,
+	} {
+		if rec := postSums(bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want %d", bad, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"net/http"
+)
+
+// reduces the posted data series to at most threshold points for plotting
+// POST /goplot/thin?method=largestTriangle&threshold=500
+func thinServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	threshold, err := intParam(req, "threshold", 500)
+	if err != nil || threshold < 3 {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	methodName := req.FormValue("method")
+	if methodName == "" {
+		methodName = "largestTriangle"
+	}
+	method, ok := compute.ThinMethods[methodName]
+	if !ok {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+
+	jsonThinned, err := json.Marshal(compute.Thin(series, threshold, method))
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonThinned)
+}
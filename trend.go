@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"math"
+	"net/http"
+)
+
+// compares the fit of the posted points to the last fit stored for a series
+type trendSample struct {
+	compute.Envelope
+	compute.Trend
+}
+
+// POST /goplot/trend?series=name
+func trendServer(c http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		serveError(c, req, http.StatusMethodNotAllowed)
+		return
+	}
+	name := req.FormValue("series")
+	if validSeriesName(name) != nil {
+		serveError(c, req, http.StatusBadRequest)
+		return
+	}
+	if !seriesAllowed(req.Header.Get("X-API-Key"), name) {
+		serveError(c, req, http.StatusForbidden)
+		return
+	}
+	series, err := compute.ParseSeries(req.FormValue("dataseries"))
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	latest := seriesFit(series)
+	if latest == nil {
+		serveError(c, req, http.StatusUnprocessableEntity)
+		return
+	}
+
+	lock := seriesLocks.Lock(name)
+	lock.RLock()
+	defer lock.RUnlock()
+	if !seriesExists(name) {
+		serveError(c, req, http.StatusNotFound)
+		return
+	}
+	stored, err := loadSeries(name)
+	if err != nil {
+		serveErrorFor(c, req, err)
+		return
+	}
+	// series saved before fits were stored along with them
+	if stored.Regression == nil {
+		stored.Regression = seriesFit(stored.Series)
+	}
+	if stored.Regression == nil {
+		serveError(c, req, http.StatusUnprocessableEntity)
+		return
+	}
+
+	trend := trendSample{Envelope: compute.NewEnvelope(),
+		Trend: compute.CompareFits(*stored.Regression, len(stored.Series), *latest, len(series))}
+	jsonTrend, err := json.Marshal(trend)
+	if err != nil {
+		serveError(c, req, http.StatusInternalServerError)
+		return
+	}
+	c.Header().Set("Content-Type", "application/json")
+	c.Write(jsonTrend)
+}
+
+// the least squares fit of a series, nil when there are too few points, or
+// too little spread, for a standard error and correlation
+func seriesFit(series []compute.Point) *compute.RegressionLine {
+	if len(series) < 3 {
+		return nil
+	}
+	line := compute.FitLine(series)
+	if math.IsNaN(line.StdError) || math.IsNaN(line.Correlation) || math.IsNaN(line.Slope) {
+		return nil
+	}
+	return &line
+}
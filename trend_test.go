@@ -0,0 +1,30 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestTrend(t *testing.T) {
+	storeSeries(t, "trending", "1,2\n2,5\n3,5\n4,9\n5,9")
+	rec := postForm(trendServer, "/goplot/trend?series=trending", url.Values{"dataseries": {"1,2\n2,4\n3,6.1\n4,8\n5,10\n6,12"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body)
+	}
+	var trend trendSample
+	if err := json.Unmarshal(rec.Body.Bytes(), &trend); err != nil {
+		t.Fatal(err)
+	}
+	if trend.TrendDirection != "improving" || trend.PointCountDelta != 1 || trend.CorrelationDelta <= 0 {
+		t.Errorf("got %+v, want improving with one more point", trend.Trend)
+	}
+
+	if rec := postForm(trendServer, "/goplot/trend?series=untracked", url.Values{"dataseries": {"1,2\n2,4\n3,7"}}); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown series: got %d, want %d", rec.Code, http.StatusNotFound)
+	}
+	if rec := postForm(trendServer, "/goplot/trend?series=trending", url.Values{"dataseries": {"1,2\n2,4"}}); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("2 points: got %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+}
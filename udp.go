@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"goplot/compute"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// largest UDP packet read; bigger ones are cut off there
+const maxUDPPacket = 64 << 10
+
+// takes "x,y" lines over UDP, any number to a packet, for sensors that
+// can't afford a connection per point. The points are buffered and appended
+// to a stored series every flush interval.
+type UDPIngest struct {
+	conn   net.PacketConn
+	series string
+	mu     sync.Mutex
+	points []compute.Point
+	done   chan struct{}
+}
+
+// listens on addr and starts reading packets and flushing them to the series
+func ListenUDP(addr string, series string, interval time.Duration) (*UDPIngest, error) {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	ingest := &UDPIngest{conn: conn, series: series, done: make(chan struct{})}
+	go ingest.read()
+	go ingest.flushEvery(interval)
+	return ingest, nil
+}
+
+func (ingest *UDPIngest) read() {
+	packet := make([]byte, maxUDPPacket)
+	for {
+		n, _, err := ingest.conn.ReadFrom(packet)
+		if err != nil {
+			// closed
+			return
+		}
+		// lines that aren't points are skipped, there is no one to tell
+		points, err := compute.ParseSeries(string(packet[:n]))
+		if err != nil || len(points) == 0 {
+			continue
+		}
+		ingest.mu.Lock()
+		if len(ingest.points)+len(points) <= config.MaxPoints {
+			ingest.points = append(ingest.points, points...)
+		}
+		ingest.mu.Unlock()
+	}
+}
+
+func (ingest *UDPIngest) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ingest.Flush()
+		case <-ingest.done:
+			return
+		}
+	}
+}
+
+// appends the buffered points to the stored series. When that fails they
+// are kept for the next try.
+func (ingest *UDPIngest) Flush() {
+	ingest.mu.Lock()
+	points := ingest.points
+	ingest.points = nil
+	ingest.mu.Unlock()
+	if len(points) == 0 {
+		return
+	}
+
+	lock := seriesLocks.Lock(ingest.series)
+	lock.Lock()
+	stored, err := loadSeries(ingest.series)
+	if err == nil {
+		stored.Series = append(stored.Series, points...)
+		err = saveSeries(ingest.series, stored)
+	}
+	lock.Unlock()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "UDP points for %s kept for the next flush: %s\n", ingest.series, err.Error())
+		ingest.mu.Lock()
+		if len(points)+len(ingest.points) <= config.MaxPoints {
+			ingest.points = append(points, ingest.points...)
+		}
+		ingest.mu.Unlock()
+	}
+}
+
+// stops taking packets and writes out what is buffered
+func (ingest *UDPIngest) Close() {
+	ingest.conn.Close()
+	close(ingest.done)
+	ingest.Flush()
+}
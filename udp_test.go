@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// sends each packet to the ingest's address
+func sendUDP(t *testing.T, ingest *UDPIngest, packets ...string) {
+	t.Helper()
+	conn, err := net.DialUDP("udp", nil, ingest.conn.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, packet := range packets {
+		if _, err := conn.Write([]byte(packet)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUDPIngest(t *testing.T) {
+	ingest, err := ListenUDP("127.0.0.1:0", "sensor", 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ingest.Close()
+	sendUDP(t, ingest, "1,2\n", "2,4\n3,6\nnot a point\n4,8\n", "5,10")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		stored, err := loadSeries("sensor")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stored.Series) == 5 {
+			if line := stored.Regression; line == nil || line.Slope != 2 {
+				t.Errorf("stored fit %v, want slope 2", line)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d points, want 5", len(stored.Series))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestUDPIngestCloseFlushes(t *testing.T) {
+	ingest, err := ListenUDP("127.0.0.1:0", "sensor_close", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendUDP(t, ingest, "1,1\n2,2\n3,3")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ingest.mu.Lock()
+		buffered := len(ingest.points)
+		ingest.mu.Unlock()
+		if buffered == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d points buffered, want 3", buffered)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if stored, _ := loadSeries("sensor_close"); len(stored.Series) != 0 {
+		t.Fatalf("flushed before the interval: %v", stored.Series)
+	}
+	ingest.Close()
+	if stored, _ := loadSeries("sensor_close"); len(stored.Series) != 3 {
+		t.Errorf("after Close: got %d points, want 3", len(stored.Series))
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"goplot/compute"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// cap on an uploaded data series once decompressed, the same as net/http
+// puts on a url-encoded form
+const maxUploadBytes = 10 << 20
+
+// how much of a multipart upload is held in memory rather than in temporary
+// files, the net/http default
+const multipartMemory = 32 << 20
+
+var errUploadTooLarge = errors.New("uploaded data series over 10 MB")
+
+// the dataseries form field, or else the contents of a dataseries file in
+// a multipart upload. A file named *.gz, or sent as gzip, is decompressed;
+// the size cap applies to the decompressed data so a small gzip bomb can't
+// blow up in memory.
+func dataSeriesFromRequest(req *http.Request) (string, error) {
+	// FormValue drops the error of a form net/http refused to read, leaving
+	// an empty series
+	if err := req.ParseForm(); err != nil {
+		if req.ContentLength > maxUploadBytes {
+			return "", errUploadTooLarge
+		}
+		return "", &compute.ParseError{Err: err}
+	}
+	// and once the form is parsed it no longer reads a multipart body
+	if err := req.ParseMultipartForm(multipartMemory); err != nil && err != http.ErrNotMultipart {
+		return "", &compute.ParseError{Err: err}
+	}
+	src := req.FormValue("dataseries")
+	if src != "" || req.MultipartForm == nil || len(req.MultipartForm.File["dataseries"]) == 0 {
+		return src, nil
+	}
+	header := req.MultipartForm.File["dataseries"][0]
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	var r io.Reader = file
+	if strings.HasSuffix(strings.ToLower(header.Filename), ".gz") ||
+		header.Header.Get("Content-Encoding") == "gzip" ||
+		header.Header.Get("Content-Type") == "application/gzip" {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return "", &compute.ParseError{Err: err}
+		}
+		defer gz.Close()
+		r = gz
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxUploadBytes+1))
+	if err != nil {
+		return "", &compute.ParseError{Err: err}
+	}
+	if len(data) > maxUploadBytes {
+		return "", errUploadTooLarge
+	}
+	return string(data), nil
+}
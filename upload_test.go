@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func gzipped(data string) []byte {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	gz.Write([]byte(data))
+	gz.Close()
+	return compressed.Bytes()
+}
+
+// a request to /goplot/viz uploading content as the dataseries file
+func uploadRequest(t *testing.T, filename, contentType string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="dataseries"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.Close()
+	req := httptest.NewRequest("POST", "/goplot/viz", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestGzipUpload(t *testing.T) {
+	data := "1,2\n2,4\n3,7\n"
+	for _, upload := range []struct{ filename, contentType string }{
+		{"data.csv.gz", "application/octet-stream"},
+		{"data.csv", "application/gzip"},
+	} {
+		src, err := dataSeriesFromRequest(uploadRequest(t, upload.filename, upload.contentType, gzipped(data)))
+		if err != nil || src != data {
+			t.Errorf("%s as %s: got %q, %v", upload.filename, upload.contentType, src, err)
+		}
+	}
+	if src, err := dataSeriesFromRequest(uploadRequest(t, "data.csv", "text/csv", []byte(data))); err != nil || src != data {
+		t.Errorf("plain upload: got %q, %v", src, err)
+	}
+
+	tests := []struct {
+		name     string
+		req      *http.Request
+		wantCode int
+	}{
+		// the cap is on the decompressed size: this compresses to a few kB
+		{"over 10 MB decompressed", uploadRequest(t, "bomb.csv.gz", "application/octet-stream",
+			gzipped(strings.Repeat("1,1\n", maxUploadBytes/4+1))), http.StatusRequestEntityTooLarge},
+		{"not gzip after all", uploadRequest(t, "data.csv.gz", "text/csv", []byte(data)), http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		dataSampleServer(rec, test.req)
+		if rec.Code != test.wantCode {
+			t.Errorf("%s: got %d, want %d", test.name, rec.Code, test.wantCode)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+//go:build js && wasm
+
+// Command wasm runs the goplot regression in the browser. It registers a
+// global computeRegression(data) function taking the same "x,y" lines as
+// the dataseries form field and returning the DataSample as JSON.
+//
+// Build it next to the other client files with
+//
+//	GOOS=js GOARCH=wasm go build -o client/goplot.wasm goplot/wasm
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" client/
+package main
+
+import (
+	"encoding/json"
+	"goplot/compute"
+	"syscall/js"
+)
+
+func computeRegression(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return errorJSON("computeRegression takes a single data string")
+	}
+	series, err := compute.ParseSeries(args[0].String())
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	jsonDataSample, err := json.Marshal(compute.NewDataSample(series, compute.Metadata{}))
+	if err != nil {
+		return errorJSON(err.Error())
+	}
+	return string(jsonDataSample)
+}
+
+func errorJSON(msg string) string {
+	jsonError, _ := json.Marshal(map[string]string{"error": msg})
+	return string(jsonError)
+}
+
+func main() {
+	js.Global().Set("computeRegression", js.FuncOf(computeRegression))
+	// keep the module alive so the callback stays valid
+	select {}
+}